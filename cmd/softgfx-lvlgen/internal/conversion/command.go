@@ -3,9 +3,12 @@ package conversion
 import (
 	"fmt"
 	"io"
+	"log"
 	"os"
 
 	"github.com/urfave/cli/v2"
+
+	"github.com/mokiat/softgfx/pkg/lvlgen"
 )
 
 func Command() cli.ActionFunc {
@@ -31,7 +34,16 @@ func Command() cli.ActionFunc {
 		}
 
 		scale := ctx.Float64("scale")
+		textureSize := ctx.Float64("texture-size")
+		skyTextures := ctx.StringSlice("sky")
+		mirrorTextures := ctx.StringSlice("mirror")
 
-		return run(in, out, scale)
+		return lvlgen.Convert(in, out,
+			lvlgen.WithScale(scale),
+			lvlgen.WithTextureSize(textureSize),
+			lvlgen.WithSkyTextures(skyTextures...),
+			lvlgen.WithMirrorTextures(mirrorTextures...),
+			lvlgen.WithLogger(log.Default()),
+		)
 	}
 }
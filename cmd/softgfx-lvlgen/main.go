@@ -7,6 +7,7 @@ import (
 	cli "github.com/urfave/cli/v2"
 
 	"github.com/mokiat/softgfx/cmd/softgfx-lvlgen/internal/conversion"
+	"github.com/mokiat/softgfx/pkg/lvlgen"
 )
 
 func main() {
@@ -26,7 +27,20 @@ func main() {
 		&cli.Float64Flag{
 			Name:  "scale",
 			Usage: "specify a scaling factor for the level",
-			Value: 64.0,
+			Value: lvlgen.DefaultScale,
+		},
+		&cli.Float64Flag{
+			Name:  "texture-size",
+			Usage: "specify the size of the textures, used to convert texture coordinates",
+			Value: lvlgen.DefaultTextureSize,
+		},
+		&cli.StringSliceFlag{
+			Name:  "sky",
+			Usage: "specify the name of a texture that shows the sky on ceilings and floors (can be repeated)",
+		},
+		&cli.StringSliceFlag{
+			Name:  "mirror",
+			Usage: "specify the name of a texture that reflects the level on wall faces (can be repeated)",
 		},
 	}
 	app.Version = "0.1.0"
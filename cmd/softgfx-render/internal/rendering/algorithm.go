@@ -0,0 +1,212 @@
+package rendering
+
+import (
+	"fmt"
+	"image"
+	"image/png"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/mokiat/softgfx/pkg/data"
+	"github.com/mokiat/softgfx/pkg/render"
+	"github.com/mokiat/softgfx/pkg/render/bsp"
+	"github.com/mokiat/softgfx/pkg/render/graphics"
+	"github.com/mokiat/softgfx/pkg/render/scene"
+)
+
+type Config struct {
+	LevelFile  string
+	TextureDir string
+	Width      int
+	Height     int
+	View       View
+
+	// Palette specifies whether the level is rendered in 8-bit palette
+	// mode instead of with 32-bit colors.
+	Palette bool
+
+	// Scale specifies the factor by which the internal resolution is
+	// lower than the image resolution. Values below one mean no scaling.
+	Scale int
+
+	// Dither specifies whether shading is dithered between adjacent
+	// shade levels.
+	Dither bool
+
+	// Gamma and Brightness adjust the colors of the image. Values of
+	// zero leave the colors unchanged, same as values of one.
+	Gamma      float32
+	Brightness float32
+
+	// CRT specifies whether scanlines and a vignette are applied to the
+	// image, which mimics the look of a CRT display.
+	CRT bool
+}
+
+// View describes the camera placement from which a level is rendered.
+type View struct {
+	X        float32
+	Y        float32
+	Z        float32
+	Rotation float32
+	Skew     float32
+
+	// FieldOfView is the horizontal field of view of the camera in
+	// degrees. If zero, scene.DefaultFieldOfView is used.
+	FieldOfView float32
+}
+
+func run(out io.Writer, config Config) error {
+	if config.Width <= 0 || config.Height <= 0 {
+		return fmt.Errorf("invalid image size: %dx%d", config.Width, config.Height)
+	}
+
+	world, err := loadWorld(config.LevelFile, config.TextureDir)
+	if err != nil {
+		return err
+	}
+	if config.Palette {
+		world = world.withPalette()
+	}
+
+	img := renderView(world, config)
+	if err := png.Encode(out, img); err != nil {
+		return fmt.Errorf("failed to encode png image: %w", err)
+	}
+	return nil
+}
+
+// world holds everything that is needed to render a level.
+type world struct {
+	RootWall     *bsp.Wall
+	Sprites      []scene.Sprite
+	Textures     []*graphics.Texture
+	ShadingTable *graphics.ShadingTable
+
+	// Palette, if set, specifies that the world is rendered in palette
+	// mode and holds the palette to which the textures are quantized.
+	Palette *graphics.Palette
+}
+
+// withPalette quantizes the textures of the world and returns a copy
+// of it that is rendered in palette mode.
+func (w world) withPalette() world {
+	textures := append(append([]*graphics.Texture(nil), w.Textures...), render.SpriteTextures(w.Sprites)...)
+	w.Palette = render.QuantizeTextures(textures, w.ShadingTable)
+	return w
+}
+
+func loadWorld(levelFile, textureDir string) (world, error) {
+	level, err := readLevel(levelFile)
+	if err != nil {
+		return world{}, fmt.Errorf("failed to read level %q: %w", levelFile, err)
+	}
+
+	loadTexture := func(name string) (data.Texture, error) {
+		return readTexture(filepath.Join(textureDir, name+".png"))
+	}
+
+	renderLevel, err := render.LoadLevel(level, loadTexture)
+	if err != nil {
+		return world{}, fmt.Errorf("failed to load level %q: %w", levelFile, err)
+	}
+
+	sprites, err := render.LoadSprites(level.Entities, loadTexture)
+	if err != nil {
+		return world{}, fmt.Errorf("failed to load sprites of level %q: %w", levelFile, err)
+	}
+
+	return world{
+		RootWall:     renderLevel.Root,
+		Sprites:      sprites,
+		Textures:     renderLevel.Textures,
+		ShadingTable: render.LevelShadingTable(level),
+	}, nil
+}
+
+// renderView renders the world into an image, as configured. The level
+// and palette settings of the configuration are not taken into account,
+// since they are part of the world.
+func renderView(world world, config Config) *image.RGBA {
+	imagePlotter := graphics.NewImagePlotter(config.Width, config.Height)
+	var pixelPlotter graphics.PixelPlotter = imagePlotter
+	if effects := postProcessEffects(config); len(effects) > 0 {
+		pixelPlotter = graphics.NewPostProcessPlotter(pixelPlotter, effects...)
+	}
+	if config.Scale > 1 {
+		pixelPlotter = graphics.NewScalingPlotter(pixelPlotter, config.Scale)
+	}
+	var plotter graphics.Plotter = pixelPlotter
+	if world.Palette != nil {
+		plotter = graphics.NewPalettePlotter(pixelPlotter, world.Palette)
+	}
+	renderer := render.NewRenderer(plotter,
+		render.WithShadingTable(world.ShadingTable),
+		render.WithDithering(config.Dither),
+	)
+
+	view := config.View
+	camera := scene.NewCamera()
+	camera.SetPosition(view.X, view.Y, view.Z)
+	camera.SetRotation(view.Rotation)
+	camera.SetSkew(view.Skew)
+	if view.FieldOfView != 0.0 {
+		camera.SetFieldOfView(view.FieldOfView)
+	}
+
+	renderer.Render(world.RootWall, world.Sprites, camera)
+	plotter.Flush()
+	return imagePlotter.Image()
+}
+
+// postProcessEffects returns the effects that are applied to the image,
+// as configured.
+func postProcessEffects(config Config) []graphics.Effect {
+	var effects []graphics.Effect
+	if (config.Gamma != 0.0 && config.Gamma != 1.0) || (config.Brightness != 0.0 && config.Brightness != 1.0) {
+		colorCorrection := graphics.NewColorCorrection()
+		if config.Gamma != 0.0 {
+			colorCorrection.SetGamma(config.Gamma)
+		}
+		if config.Brightness != 0.0 {
+			colorCorrection.SetBrightness(config.Brightness)
+		}
+		effects = append(effects, colorCorrection)
+	}
+	if config.CRT {
+		effects = append(effects,
+			&graphics.Scanlines{Intensity: 0.3},
+			&graphics.Vignette{Intensity: 0.5},
+		)
+	}
+	return effects
+}
+
+func readLevel(path string) (data.Level, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return data.Level{}, fmt.Errorf("failed to open file: %w", err)
+	}
+	defer file.Close()
+
+	level, err := data.LoadLevel(file)
+	if err != nil {
+		return data.Level{}, fmt.Errorf("failed to load level: %w", err)
+	}
+	return level, nil
+}
+
+func readTexture(path string) (data.Texture, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return data.Texture{}, fmt.Errorf("failed to open file: %w", err)
+	}
+	defer file.Close()
+
+	texture, err := data.LoadTexture(file)
+	if err != nil {
+		return data.Texture{}, fmt.Errorf("failed to load texture: %w", err)
+	}
+	return texture, nil
+}
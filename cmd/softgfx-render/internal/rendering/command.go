@@ -0,0 +1,45 @@
+package rendering
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/urfave/cli/v2"
+)
+
+func Command() cli.ActionFunc {
+	return func(ctx *cli.Context) error {
+		var out io.Writer = os.Stdout
+		if outFilePath := ctx.String("out"); outFilePath != "" {
+			outFile, err := os.Create(outFilePath)
+			if err != nil {
+				return fmt.Errorf("failed to create output file: %w", err)
+			}
+			defer outFile.Close()
+			out = outFile
+		}
+
+		return run(out, Config{
+			LevelFile:  ctx.String("level"),
+			TextureDir: ctx.String("textures"),
+			Width:      ctx.Int("width"),
+			Height:     ctx.Int("height"),
+			View: View{
+				X:           float32(ctx.Float64("x")),
+				Y:           float32(ctx.Float64("y")),
+				Z:           float32(ctx.Float64("z")),
+				Rotation:    float32(ctx.Float64("rotation")),
+				Skew:        float32(ctx.Float64("skew")),
+				FieldOfView: float32(ctx.Float64("fov")),
+			},
+			Palette: ctx.Bool("palette"),
+			Scale:   ctx.Int("scale"),
+			Dither:  ctx.Bool("dither"),
+
+			Gamma:      float32(ctx.Float64("gamma")),
+			Brightness: float32(ctx.Float64("brightness")),
+			CRT:        ctx.Bool("crt"),
+		})
+	}
+}
@@ -0,0 +1,259 @@
+package rendering
+
+import (
+	"flag"
+	"fmt"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/mokiat/softgfx/pkg/render/bsp"
+)
+
+// Run `go test ./cmd/softgfx-render/internal/rendering -update` to
+// regenerate the reference images after an intended rendering change.
+var update = flag.Bool("update", false, "regenerate golden reference images")
+
+const (
+	goldenWidth  = 320
+	goldenHeight = 240
+
+	// goldenChannelTolerance is the maximum difference of a single color
+	// channel for two pixels to be considered equal.
+	goldenChannelTolerance = 8
+
+	// goldenMismatchTolerance is the maximum ratio of pixels that are
+	// allowed to differ from the reference image.
+	goldenMismatchTolerance = 0.001
+
+	// goldenDitherMinRatio is the minimum ratio of pixels that have to
+	// differ when a dithered case is rendered without dithering. Dithering
+	// only moves pixels by a single shade level, which is well within the
+	// tolerances of the reference images, so it is checked separately.
+	goldenDitherMinRatio = 0.1
+)
+
+type goldenTest struct {
+	Name    string
+	Level   string
+	View    View
+	Palette bool
+	Scale   int
+	Dither  bool
+	Gamma   float32
+	CRT     bool
+	Mirror  *mirrorArea
+}
+
+var goldenCases = []goldenTest{
+	{Name: "castle-origin", Level: "castle", View: View{}},
+	{Name: "castle-right", Level: "castle", View: View{Rotation: 90}},
+	{Name: "castle-back", Level: "castle", View: View{Rotation: 180}},
+	{Name: "castle-left", Level: "castle", View: View{Rotation: 270}},
+	{Name: "castle-look-up", Level: "castle", View: View{Y: -60, Rotation: 30, Skew: 0.6}},
+	{Name: "castle-look-down", Level: "castle", View: View{Rotation: 135, Skew: -0.5}},
+	{Name: "default-corridor", Level: "default", View: View{Z: 300}},
+	{Name: "default-corridor-back", Level: "default", View: View{Z: 300, Rotation: 180, Skew: 0.3}},
+	{Name: "default-platform", Level: "default", View: View{X: -60, Y: 20, Z: 100, Rotation: 300, Skew: -0.3}},
+	{Name: "castle-zoom", Level: "castle", View: View{Rotation: 90, FieldOfView: 50}},
+	{Name: "castle-look-up-palette", Level: "castle", View: View{Y: -60, Rotation: 30, Skew: 0.6}, Palette: true},
+	{Name: "castle-scaled", Level: "castle", View: View{Rotation: 45}, Scale: 3},
+	{Name: "castle-look-down-dither", Level: "castle", View: View{Rotation: 135, Skew: -0.5}, Dither: true},
+	{Name: "castle-look-down-palette-dither", Level: "castle", View: View{Rotation: 135, Skew: -0.5}, Palette: true, Dither: true},
+	{Name: "castle-post-process", Level: "castle", View: View{Rotation: 270}, Gamma: 1.5, CRT: true},
+	{Name: "castle-mirror", Level: "castle", View: View{X: -60, Z: 1000, Rotation: 165}, Mirror: &mirrorArea{MinX: -147.0, MinZ: 804.0, MaxX: 102.0, MaxZ: 805.0}},
+}
+
+func TestGoldenImages(t *testing.T) {
+	worlds := make(map[string]world)
+	for _, goldenCase := range goldenCases {
+		goldenCase := goldenCase
+		t.Run(goldenCase.Name, func(t *testing.T) {
+			actual := renderGoldenTest(t, worlds, goldenCase)
+			goldenFile := filepath.Join("testdata", "golden", goldenCase.Name+".png")
+			if *update {
+				writeImage(t, goldenFile, actual)
+				return
+			}
+
+			expected := readImage(t, goldenFile)
+			if err := compareImages(expected, actual); err != nil {
+				actualFile := filepath.Join(t.TempDir(), goldenCase.Name+".png")
+				writeImage(t, actualFile, actual)
+				t.Fatalf("image differs from %q (actual written to %q): %v", goldenFile, actualFile, err)
+			}
+		})
+	}
+}
+
+func TestGoldenImagesDithering(t *testing.T) {
+	worlds := make(map[string]world)
+	for _, goldenCase := range goldenCases {
+		if !goldenCase.Dither {
+			continue
+		}
+		goldenCase := goldenCase
+		t.Run(goldenCase.Name, func(t *testing.T) {
+			dithered := renderGoldenTest(t, worlds, goldenCase)
+			goldenCase.Dither = false
+			undithered := renderGoldenTest(t, worlds, goldenCase)
+
+			bounds := dithered.Bounds()
+			differences := 0
+			for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+				for x := bounds.Min.X; x < bounds.Max.X; x++ {
+					if dithered.At(x, y) != undithered.At(x, y) {
+						differences++
+					}
+				}
+			}
+			pixelCount := bounds.Dx() * bounds.Dy()
+			if ratio := float64(differences) / float64(pixelCount); ratio < goldenDitherMinRatio {
+				t.Fatalf("expected dithering to change at least %f of the pixels, got %d of %d (ratio: %f)", goldenDitherMinRatio, differences, pixelCount, ratio)
+			}
+		})
+	}
+}
+
+// renderGoldenTest renders the view of the specified golden test. The
+// levels are loaded once and kept in the specified map.
+func renderGoldenTest(t *testing.T, worlds map[string]world, goldenCase goldenTest) image.Image {
+	t.Helper()
+	levelWorld, ok := worlds[goldenCase.Level]
+	if !ok {
+		var err error
+		levelWorld, err = loadWorld(
+			filepath.Join("..", "..", "..", "..", "web", "levels", goldenCase.Level+".json"),
+			filepath.Join("..", "..", "..", "..", "web", "images"),
+		)
+		if err != nil {
+			t.Fatalf("failed to load level: %v", err)
+		}
+		worlds[goldenCase.Level] = levelWorld
+	}
+
+	if goldenCase.Palette {
+		levelWorld = levelWorld.withPalette()
+	}
+	if goldenCase.Mirror != nil {
+		levelWorld = levelWorld.withMirrors(*goldenCase.Mirror)
+	}
+
+	return renderView(levelWorld, Config{
+		Width:  goldenWidth,
+		Height: goldenHeight,
+		View:   goldenCase.View,
+		Scale:  goldenCase.Scale,
+		Dither: goldenCase.Dither,
+		Gamma:  goldenCase.Gamma,
+		CRT:    goldenCase.CRT,
+	})
+}
+
+// mirrorArea is a rectangle in world space, within which the faces of
+// walls are turned into mirrors, since the levels do not contain any.
+type mirrorArea struct {
+	MinX float32
+	MinZ float32
+	MaxX float32
+	MaxZ float32
+}
+
+func (a mirrorArea) Contains(x, z float32) bool {
+	return (x >= a.MinX) && (x <= a.MaxX) && (z >= a.MinZ) && (z <= a.MaxZ)
+}
+
+// withMirrors returns a copy of the world, where the faces of the walls
+// that are completely within the specified area are mirrors.
+func (w world) withMirrors(area mirrorArea) world {
+	var mirrorTree func(wall *bsp.Wall) *bsp.Wall
+	mirrorTree = func(wall *bsp.Wall) *bsp.Wall {
+		if wall == nil {
+			return nil
+		}
+		result := *wall
+		if area.Contains(wall.LeftEdgeX, wall.LeftEdgeZ) && area.Contains(wall.RightEdgeX, wall.RightEdgeZ) {
+			if wall.HasCeilingExtrusion() {
+				ceiling := *wall.Ceiling
+				ceiling.Mirror = true
+				result.Ceiling = &ceiling
+			}
+			if wall.HasFloorExtrusion() {
+				floor := *wall.Floor
+				floor.Mirror = true
+				result.Floor = &floor
+			}
+		}
+		result.FrontWall = mirrorTree(wall.FrontWall)
+		result.BackWall = mirrorTree(wall.BackWall)
+		return &result
+	}
+	w.RootWall = mirrorTree(w.RootWall)
+	return w
+}
+
+func compareImages(expected, actual image.Image) error {
+	if expected.Bounds() != actual.Bounds() {
+		return fmt.Errorf("expected bounds %v, got %v", expected.Bounds(), actual.Bounds())
+	}
+
+	bounds := expected.Bounds()
+	mismatches := 0
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			expectedR, expectedG, expectedB, expectedA := expected.At(x, y).RGBA()
+			actualR, actualG, actualB, actualA := actual.At(x, y).RGBA()
+			isEqual := channelsEqual(expectedR, actualR) &&
+				channelsEqual(expectedG, actualG) &&
+				channelsEqual(expectedB, actualB) &&
+				channelsEqual(expectedA, actualA)
+			if !isEqual {
+				mismatches++
+			}
+		}
+	}
+
+	pixelCount := bounds.Dx() * bounds.Dy()
+	if ratio := float64(mismatches) / float64(pixelCount); ratio > goldenMismatchTolerance {
+		return fmt.Errorf("%d of %d pixels differ (ratio: %f)", mismatches, pixelCount, ratio)
+	}
+	return nil
+}
+
+func channelsEqual(expected, actual uint32) bool {
+	delta := int(expected>>8) - int(actual>>8)
+	return delta >= -goldenChannelTolerance && delta <= goldenChannelTolerance
+}
+
+func readImage(t *testing.T, path string) image.Image {
+	t.Helper()
+	file, err := os.Open(path)
+	if err != nil {
+		t.Fatalf("failed to open golden image (run with -update to create it): %v", err)
+	}
+	defer file.Close()
+
+	img, err := png.Decode(file)
+	if err != nil {
+		t.Fatalf("failed to decode golden image: %v", err)
+	}
+	return img
+}
+
+func writeImage(t *testing.T, path string, img image.Image) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatalf("failed to create image directory: %v", err)
+	}
+	file, err := os.Create(path)
+	if err != nil {
+		t.Fatalf("failed to create image file: %v", err)
+	}
+	defer file.Close()
+
+	if err := png.Encode(file, img); err != nil {
+		t.Fatalf("failed to encode image: %v", err)
+	}
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"log"
+	"os"
+
+	cli "github.com/urfave/cli/v2"
+
+	"github.com/mokiat/softgfx/cmd/softgfx-render/internal/rendering"
+	"github.com/mokiat/softgfx/pkg/render/scene"
+)
+
+func main() {
+	app := cli.NewApp()
+	app.Name = "softgfx-render"
+	app.Usage = "render softgfx levels to png images"
+	app.UsageText = "softgfx-render --level level_file [--textures texture_dir] [--out png_file] [camera options]"
+	app.Flags = []cli.Flag{
+		&cli.StringFlag{
+			Name:     "level",
+			Usage:    "specify a json level file to render",
+			Required: true,
+		},
+		&cli.StringFlag{
+			Name:  "textures",
+			Usage: "specify a directory from which to load png textures",
+			Value: "web/images",
+		},
+		&cli.StringFlag{
+			Name:  "out",
+			Usage: "specify a file to write png image to (by default STDOUT is used)",
+		},
+		&cli.IntFlag{
+			Name:  "width",
+			Usage: "specify the width of the image in pixels",
+			Value: 640,
+		},
+		&cli.IntFlag{
+			Name:  "height",
+			Usage: "specify the height of the image in pixels",
+			Value: 480,
+		},
+		&cli.Float64Flag{
+			Name:  "x",
+			Usage: "specify the X position of the camera",
+		},
+		&cli.Float64Flag{
+			Name:  "y",
+			Usage: "specify the Y position of the camera (positive values are downward)",
+		},
+		&cli.Float64Flag{
+			Name:  "z",
+			Usage: "specify the Z position of the camera",
+		},
+		&cli.Float64Flag{
+			Name:  "rotation",
+			Usage: "specify the rotation of the camera in degrees",
+		},
+		&cli.Float64Flag{
+			Name:  "skew",
+			Usage: "specify the vertical skew of the camera (positive values look upward)",
+		},
+		&cli.Float64Flag{
+			Name:  "fov",
+			Usage: "specify the horizontal field of view of the camera in degrees",
+			Value: float64(scene.DefaultFieldOfView),
+		},
+		&cli.IntFlag{
+			Name:  "scale",
+			Usage: "specify the factor by which the internal resolution is lower than the image resolution",
+			Value: 1,
+		},
+		&cli.BoolFlag{
+			Name:  "palette",
+			Usage: "render with an 8-bit palette instead of 32-bit colors",
+		},
+		&cli.BoolFlag{
+			Name:  "dither",
+			Usage: "dither shading between adjacent shade levels",
+		},
+		&cli.Float64Flag{
+			Name:  "gamma",
+			Usage: "specify the gamma correction of the image (values above 1 brighten dark tones)",
+			Value: 1.0,
+		},
+		&cli.Float64Flag{
+			Name:  "brightness",
+			Usage: "specify the factor by which the colors of the image are multiplied",
+			Value: 1.0,
+		},
+		&cli.BoolFlag{
+			Name:  "crt",
+			Usage: "apply scanlines and a vignette to the image",
+		},
+	}
+	app.Version = "0.1.0"
+	app.Action = rendering.Command()
+	if err := app.Run(os.Args); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -2,35 +2,79 @@ package game
 
 import (
 	"fmt"
-	"math"
 	"net/http"
 	"net/url"
 	"sync"
+	"time"
 
-	"github.com/mokiat/softgfx/cmd/softgfx-wasm/internal/bsp"
-	"github.com/mokiat/softgfx/cmd/softgfx-wasm/internal/graphics"
 	"github.com/mokiat/softgfx/cmd/softgfx-wasm/internal/input"
 	"github.com/mokiat/softgfx/cmd/softgfx-wasm/internal/metrics"
-	"github.com/mokiat/softgfx/cmd/softgfx-wasm/internal/scene"
-	"github.com/mokiat/softgfx/internal/data"
+	"github.com/mokiat/softgfx/pkg/data"
+	"github.com/mokiat/softgfx/pkg/render"
+	"github.com/mokiat/softgfx/pkg/render/bsp"
+	"github.com/mokiat/softgfx/pkg/render/graphics"
+	"github.com/mokiat/softgfx/pkg/render/scene"
 )
 
 const (
 	turnSpeed = float32(120.0)
 	walkSpeed = float32(125.0)
 	runSpeed  = float32(200.0)
-	jumpSpeed = float32(125.0)
+	flySpeed  = float32(125.0)
 	lookSpeed = float32(1.0)
-)
+	zoomSpeed = float32(240.0)
+
+	zoomFieldOfView = float32(40.0)
+
+	brightnessSpeed = float32(0.5)
+	minBrightness   = float32(0.5)
+	maxBrightness   = float32(2.0)
+
+	// flashFadeSeconds is the time it takes for a damage or pickup
+	// flash to fade out.
+	flashFadeSeconds = float32(0.5)
+
+	underwaterTintAmount = float32(0.35)
+
+	// maxScale is the largest factor by which the internal resolution
+	// can be lower than the canvas resolution.
+	maxScale = 3
 
-func NewApplication(keyboard *input.Keyboard, plotter *graphics.Plotter) *Application {
-	sceneRenderer := scene.NewRenderer(plotter)
-	bspRenderer := bsp.NewRenderer(sceneRenderer)
+	// frameBudget is the render time per frame that the dynamic scale
+	// tries to stay within.
+	frameBudget = 12 * time.Millisecond
 
+	// scaleSampleFrames is the number of frames over which render times
+	// are averaged before the dynamic scale is adjusted.
+	scaleSampleFrames = 60
+
+	gravity     = float32(800.0)
+	jumpImpulse = float32(250.0)
+
+	playerRadius     = float32(16.0)
+	playerHeadroom   = float32(8.0)
+	playerEyeHeight  = float32(96.0)
+	playerStepHeight = float32(24.0)
+)
+
+func NewApplication(keyboard *input.Keyboard, plotter graphics.PixelPlotter) *Application {
+	colorCorrection := graphics.NewColorCorrection()
+	environmentTint := &graphics.Tint{}
+	flashTint := &graphics.Tint{}
+	postProcessPlotter := graphics.NewPostProcessPlotter(plotter, colorCorrection, environmentTint, flashTint)
+	scalingPlotter := graphics.NewScalingPlotter(postProcessPlotter, 1)
 	return &Application{
-		keyboard:    keyboard,
-		plotter:     plotter,
-		bspRenderer: bspRenderer,
+		keyboard:       keyboard,
+		plotter:        scalingPlotter,
+		scalingPlotter: scalingPlotter,
+		renderer:       render.NewRenderer(scalingPlotter),
+
+		postProcessPlotter: postProcessPlotter,
+		colorCorrection:    colorCorrection,
+		environmentTint:    environmentTint,
+		flashTint:          flashTint,
+		scanlines:          &graphics.Scanlines{Intensity: 0.3},
+		vignette:           &graphics.Vignette{Intensity: 0.5},
 
 		initializedMU: &sync.Mutex{},
 		initialized:   false,
@@ -40,14 +84,42 @@ func NewApplication(keyboard *input.Keyboard, plotter *graphics.Plotter) *Applic
 
 type Application struct {
 	keyboard       *input.Keyboard
-	plotter        *graphics.Plotter
-	bspRenderer    *bsp.Renderer
+	plotter        graphics.PixelPlotter
+	renderer       *render.Renderer
 	renderDuration metrics.Duration
 
+	shadingTable         *graphics.ShadingTable
+	palettePlotter       *graphics.PalettePlotter
+	paletteMode          bool
+	paletteKeyWasPressed bool
+
+	scalingPlotter     *graphics.ScalingPlotter
+	dynamicScale       bool
+	scaleKeyWasPressed bool
+
+	dithering           bool
+	ditherKeyWasPressed bool
+
+	postProcessPlotter      *graphics.PostProcessPlotter
+	colorCorrection         *graphics.ColorCorrection
+	environmentTint         *graphics.Tint
+	flashTint               *graphics.Tint
+	scanlines               *graphics.Scanlines
+	vignette                *graphics.Vignette
+	underwaterKeyWasPressed bool
+	crtMode                 bool
+	crtKeyWasPressed        bool
+
 	initializedMU *sync.Mutex
 	initialized   bool
 	camera        *scene.Camera
+	level         *render.Level
 	rootWall      *bsp.Wall
+	sprites       []scene.Sprite
+
+	flying           bool
+	flyKeyWasPressed bool
+	fallSpeed        float32
 }
 
 func (a *Application) Init(level string) {
@@ -70,16 +142,30 @@ func (a *Application) OnUpdate(elapsedSeconds float32) {
 	}
 
 	a.updatePlayer(elapsedSeconds)
+	a.updatePaletteMode()
+	a.updateScaleMode()
+	a.updateDithering()
+	a.updateEffects(elapsedSeconds)
+	a.level.Animate(elapsedSeconds)
 	a.renderDuration.Measure(func() {
-		a.bspRenderer.Clear()
-		a.bspRenderer.RenderBSP(a.rootWall, a.camera)
+		a.renderer.Render(a.rootWall, a.sprites, a.camera)
 	})
-	a.plotter.Flush()
+	a.activePlotter().Flush()
 
-	a.renderDuration.Print(60)
+	a.renderDuration.Print(scaleSampleFrames)
+	if a.dynamicScale {
+		a.adjustScale()
+	}
 }
 
 func (a *Application) updatePlayer(elapsedSeconds float32) {
+	flyKeyPressed := a.keyboard.IsKeyPressed(input.KeyName("f"))
+	if flyKeyPressed && !a.flyKeyWasPressed {
+		a.flying = !a.flying
+		a.fallSpeed = 0.0
+	}
+	a.flyKeyWasPressed = flyKeyPressed
+
 	if a.keyboard.IsKeyPressed(input.KeyNameUp) || a.keyboard.IsKeyPressed(input.KeyName("w")) {
 		a.camera.MoveForward(runSpeed * elapsedSeconds)
 	}
@@ -98,11 +184,11 @@ func (a *Application) updatePlayer(elapsedSeconds float32) {
 	if a.keyboard.IsKeyPressed(input.KeyNameRight) {
 		a.camera.TurnRight(turnSpeed * elapsedSeconds)
 	}
-	if a.keyboard.IsKeyPressed(input.KeyNameSpace) {
-		a.camera.MoveUp(jumpSpeed * elapsedSeconds)
+	if a.flying && a.keyboard.IsKeyPressed(input.KeyNameSpace) {
+		a.camera.MoveUp(flySpeed * elapsedSeconds)
 	}
-	if a.keyboard.IsKeyPressed(input.KeyNameShift) {
-		a.camera.MoveDown(jumpSpeed * elapsedSeconds)
+	if a.flying && a.keyboard.IsKeyPressed(input.KeyNameShift) {
+		a.camera.MoveDown(flySpeed * elapsedSeconds)
 	}
 	if a.keyboard.IsKeyPressed(input.KeyName("q")) {
 		a.camera.LookUp(lookSpeed * elapsedSeconds)
@@ -110,75 +196,256 @@ func (a *Application) updatePlayer(elapsedSeconds float32) {
 	if a.keyboard.IsKeyPressed(input.KeyName("e")) {
 		a.camera.LookDown(lookSpeed * elapsedSeconds)
 	}
+	a.zoomCamera(elapsedSeconds)
+	a.collidePlayer()
+	if !a.flying {
+		a.fallPlayer(elapsedSeconds)
+	}
 }
 
-func (a *Application) initScene(levelName string) error {
-	level, err := fetchLevel(levelName)
-	if err != nil {
-		return fmt.Errorf("failed to fetch level %q: %w", levelName, err)
+// updatePaletteMode switches between 32-bit and 8-bit palette rendering
+// when the palette key is pressed.
+func (a *Application) updatePaletteMode() {
+	paletteKeyPressed := a.keyboard.IsKeyPressed(input.KeyName("p"))
+	if paletteKeyPressed && !a.paletteKeyWasPressed {
+		a.paletteMode = !a.paletteMode
+		if a.paletteMode && a.palettePlotter == nil {
+			a.initPalettePlotter()
+		}
+		a.resetRenderer()
 	}
+	a.paletteKeyWasPressed = paletteKeyPressed
+}
+
+// initPalettePlotter quantizes the textures of the current level to a
+// palette and creates the plotter that uses it. This is deferred until
+// palette mode is first enabled, since quantization is costly.
+func (a *Application) initPalettePlotter() {
+	textures := append(append([]*graphics.Texture(nil), a.level.Textures...), render.SpriteTextures(a.sprites)...)
+	palette := render.QuantizeTextures(textures, a.shadingTable)
+	a.palettePlotter = graphics.NewPalettePlotter(a.plotter, palette)
+}
 
-	textures := make([]*graphics.Texture, len(level.Textures))
-	for i, textureName := range level.Textures {
-		texture, err := fetchTexture(textureName)
-		if err != nil {
-			return fmt.Errorf("failed ot fetch texture %q: %w", textureName, err)
+// updateDithering toggles the dithering of shading when the dither key
+// is pressed.
+func (a *Application) updateDithering() {
+	ditherKeyPressed := a.keyboard.IsKeyPressed(input.KeyName("b"))
+	if ditherKeyPressed && !a.ditherKeyWasPressed {
+		a.dithering = !a.dithering
+		a.renderer.SceneRenderer().SetDithering(a.dithering)
+	}
+	a.ditherKeyWasPressed = ditherKeyPressed
+}
+
+// updateEffects adjusts the post-processing effects based on the pressed
+// keys and fades out any tint flash. Since there is no damage, nor items
+// to pick up, in the demo, the tint flashes can be triggered by keys.
+func (a *Application) updateEffects(elapsedSeconds float32) {
+	brightness := a.colorCorrection.Brightness()
+	if a.keyboard.IsKeyPressed(input.KeyName("=")) {
+		brightness += brightnessSpeed * elapsedSeconds
+		if brightness > maxBrightness {
+			brightness = maxBrightness
+		}
+		a.colorCorrection.SetBrightness(brightness)
+	}
+	if a.keyboard.IsKeyPressed(input.KeyName("-")) {
+		brightness -= brightnessSpeed * elapsedSeconds
+		if brightness < minBrightness {
+			brightness = minBrightness
 		}
-		textures[i] = convertTexture(texture)
+		a.colorCorrection.SetBrightness(brightness)
 	}
 
-	getTexture := func(index int) *graphics.Texture {
-		if index < 0 || index >= len(textures) {
-			return nil
+	a.flashTint.Update(elapsedSeconds)
+	if a.keyboard.IsKeyPressed(input.KeyName("1")) {
+		a.flashTint.Flash(graphics.DamageTint, 0.5, flashFadeSeconds)
+	}
+	if a.keyboard.IsKeyPressed(input.KeyName("2")) {
+		a.flashTint.Flash(graphics.PickupTint, 0.3, flashFadeSeconds)
+	}
+
+	underwaterKeyPressed := a.keyboard.IsKeyPressed(input.KeyName("3"))
+	if underwaterKeyPressed && !a.underwaterKeyWasPressed {
+		if a.environmentTint.Amount() > 0.0 {
+			a.environmentTint.Clear()
+		} else {
+			a.environmentTint.Set(graphics.UnderwaterTint, underwaterTintAmount)
 		}
-		return textures[index]
-	}
-
-	walls := make([]*bsp.Wall, len(level.Walls))
-	for i, levelWall := range level.Walls {
-		deltaX := float64(levelWall.RightEdgeX - levelWall.LeftEdgeX)
-		deltaZ := float64(levelWall.RightEdgeZ - levelWall.LeftEdgeZ)
-		wall := &bsp.Wall{
-			LeftEdgeX:  levelWall.LeftEdgeX,
-			LeftEdgeZ:  levelWall.LeftEdgeZ,
-			RightEdgeX: levelWall.RightEdgeX,
-			RightEdgeZ: levelWall.RightEdgeZ,
-			Length:     float32(math.Sqrt(deltaX*deltaX + deltaZ*deltaZ)),
+	}
+	a.underwaterKeyWasPressed = underwaterKeyPressed
+
+	crtKeyPressed := a.keyboard.IsKeyPressed(input.KeyName("c"))
+	if crtKeyPressed && !a.crtKeyWasPressed {
+		a.crtMode = !a.crtMode
+		effects := []graphics.Effect{a.colorCorrection, a.environmentTint, a.flashTint}
+		if a.crtMode {
+			effects = append(effects, a.scanlines, a.vignette)
 		}
-		if levelWall.Ceiling != nil {
-			wall.Ceiling = &bsp.Extrusion{
-				Top:          levelWall.Ceiling.Top,
-				Bottom:       levelWall.Ceiling.Bottom,
-				OuterTexture: getTexture(levelWall.Ceiling.OuterTexture),
-				FaceTexture:  getTexture(levelWall.Ceiling.FaceTexture),
-				InnerTexture: getTexture(levelWall.Ceiling.InnerTexture),
-			}
+		a.postProcessPlotter.SetEffects(effects...)
+	}
+	a.crtKeyWasPressed = crtKeyPressed
+}
+
+// updateScaleMode cycles through the fixed scales of the internal
+// resolution, followed by the dynamic scale, when the scale key is
+// pressed.
+func (a *Application) updateScaleMode() {
+	scaleKeyPressed := a.keyboard.IsKeyPressed(input.KeyName("r"))
+	if scaleKeyPressed && !a.scaleKeyWasPressed {
+		switch scale := a.scalingPlotter.Scale(); {
+		case a.dynamicScale:
+			a.dynamicScale = false
+			a.scalingPlotter.SetScale(1)
+		case scale < maxScale:
+			a.scalingPlotter.SetScale(scale + 1)
+		default:
+			a.dynamicScale = true
+			a.renderDuration.Reset()
 		}
-		if levelWall.Floor != nil {
-			wall.Floor = &bsp.Extrusion{
-				Top:          levelWall.Floor.Top,
-				Bottom:       levelWall.Floor.Bottom,
-				OuterTexture: getTexture(levelWall.Floor.OuterTexture),
-				FaceTexture:  getTexture(levelWall.Floor.FaceTexture),
-				InnerTexture: getTexture(levelWall.Floor.InnerTexture),
-			}
+	}
+	a.scaleKeyWasPressed = scaleKeyPressed
+}
+
+// adjustScale picks the scale of the internal resolution based on the
+// recent render times, so that frames stay within the frame budget.
+// A finer scale is only picked if the render time, which is assumed to
+// be proportional to the number of pixels, would stay well within it.
+func (a *Application) adjustScale() {
+	if a.renderDuration.Iterations() < scaleSampleFrames {
+		return
+	}
+	average := a.renderDuration.Average()
+	a.renderDuration.Reset()
+
+	scale := a.scalingPlotter.Scale()
+	switch {
+	case (average > frameBudget) && (scale < maxScale):
+		a.scalingPlotter.SetScale(scale + 1)
+	case scale > 1:
+		finerAverage := average * time.Duration(scale*scale) / time.Duration((scale-1)*(scale-1))
+		if finerAverage < frameBudget*3/4 {
+			a.scalingPlotter.SetScale(scale - 1)
 		}
-		walls[i] = wall
 	}
-	for i, levelWall := range level.Walls {
-		if frontIndex := levelWall.FrontWall; frontIndex >= 0 {
-			walls[i].FrontWall = walls[frontIndex]
+}
+
+// activePlotter returns the plotter of the current rendering mode.
+func (a *Application) activePlotter() graphics.Plotter {
+	if a.paletteMode {
+		return a.palettePlotter
+	}
+	return a.plotter
+}
+
+// resetRenderer creates a new renderer that draws onto the plotter of
+// the current rendering mode.
+func (a *Application) resetRenderer() {
+	a.renderer = render.NewRenderer(a.activePlotter(),
+		render.WithShadingTable(a.shadingTable),
+		render.WithDithering(a.dithering),
+	)
+}
+
+// zoomCamera narrows the field of view of the camera while the zoom key
+// is held and restores it once the key is released.
+func (a *Application) zoomCamera(elapsedSeconds float32) {
+	fov := a.camera.FieldOfView()
+	if a.keyboard.IsKeyPressed(input.KeyName("z")) {
+		fov -= zoomSpeed * elapsedSeconds
+		if fov < zoomFieldOfView {
+			fov = zoomFieldOfView
 		}
-		if backIndex := levelWall.BackWall; backIndex >= 0 {
-			walls[i].BackWall = walls[backIndex]
+	} else {
+		fov += zoomSpeed * elapsedSeconds
+		if fov > scene.DefaultFieldOfView {
+			fov = scene.DefaultFieldOfView
 		}
 	}
+	a.camera.SetFieldOfView(fov)
+}
+
+// collidePlayer prevents the player from walking through walls by pushing
+// the camera out of any wall that blocks the player's height range.
+// Obstacles that are lower than the step height do not block the player.
+func (a *Application) collidePlayer() {
+	body := bsp.Collide(a.rootWall, bsp.Body{
+		X:      a.camera.X(),
+		Z:      a.camera.Z(),
+		Radius: playerRadius,
+		Top:    a.camera.Y() - playerHeadroom,
+		Bottom: a.camera.Y() + playerEyeHeight - playerStepHeight,
+	})
+	a.camera.SetPosition(body.X, a.camera.Y(), body.Z)
+}
+
+// fallPlayer applies gravity and jumping to the player and keeps the
+// player's feet on the floor beneath. Since collidePlayer lets the player
+// onto obstacles that are lower than the step height, the snapping to the
+// floor is what makes the player climb steps.
+func (a *Application) fallPlayer(elapsedSeconds float32) {
+	x, y, z := a.camera.X(), a.camera.Y(), a.camera.Z()
+	floorY, hasFloor := bsp.FloorHeight(a.rootWall, x, z)
+	if !hasFloor {
+		return
+	}
+
+	isOnGround := (a.fallSpeed >= 0.0) && (y+playerEyeHeight >= floorY)
+	if isOnGround && a.keyboard.IsKeyPressed(input.KeyNameSpace) {
+		a.fallSpeed = -jumpImpulse
+	}
+	a.fallSpeed += gravity * elapsedSeconds
+	y += a.fallSpeed * elapsedSeconds
+
+	if ceilingY, hasCeiling := bsp.CeilingHeight(a.rootWall, x, z); hasCeiling && (y-playerHeadroom < ceilingY) {
+		y = ceilingY + playerHeadroom
+		if a.fallSpeed < 0.0 {
+			a.fallSpeed = 0.0
+		}
+	}
+	if y+playerEyeHeight >= floorY {
+		y = floorY - playerEyeHeight
+		a.fallSpeed = 0.0
+	}
+	a.camera.SetPosition(x, y, z)
+}
+
+func (a *Application) initScene(levelName string) error {
+	level, err := fetchLevel(levelName)
+	if err != nil {
+		return fmt.Errorf("failed to fetch level %q: %w", levelName, err)
+	}
+
+	renderLevel, err := render.LoadLevel(level, fetchTexture)
+	if err != nil {
+		return fmt.Errorf("failed to load level %q: %w", levelName, err)
+	}
+
+	sprites, err := render.LoadSprites(level.Entities, fetchTexture)
+	if err != nil {
+		return fmt.Errorf("failed to load sprites of level %q: %w", levelName, err)
+	}
+
+	shadingTable := render.LevelShadingTable(level)
 
 	a.initializedMU.Lock()
 	defer a.initializedMU.Unlock()
-	a.camera.SetPosition(0.0, 0.0, 0.0)
-	a.camera.SetRotation(0.0)
-	a.rootWall = walls[0]
+	if start := level.Start; start != nil {
+		a.camera.SetPosition(start.X, start.Y-playerEyeHeight, start.Z)
+		a.camera.SetRotation(start.Angle)
+	} else {
+		a.camera.SetPosition(0.0, 0.0, 0.0)
+		a.camera.SetRotation(0.0)
+	}
+	a.level = renderLevel
+	a.rootWall = renderLevel.Root
+	a.sprites = sprites
+	a.shadingTable = shadingTable
+	a.palettePlotter = nil
+	if a.paletteMode {
+		a.initPalettePlotter()
+	}
+	a.resetRenderer()
 	a.initialized = true
 
 	return nil
@@ -211,11 +478,3 @@ func fetchTexture(name string) (data.Texture, error) {
 	}
 	return texture, nil
 }
-
-func convertTexture(original data.Texture) *graphics.Texture {
-	return &graphics.Texture{
-		Width:  original.Width,
-		Height: original.Height,
-		Texels: original.Texels,
-	}
-}
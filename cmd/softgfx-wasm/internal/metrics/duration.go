@@ -17,6 +17,26 @@ func (d *Duration) Measure(fn func()) {
 	d.durationSum += time.Since(startTime)
 }
 
+// Iterations returns the number of measurements since the last Reset.
+func (d *Duration) Iterations() int {
+	return d.iterations
+}
+
+// Average returns the average duration of the measurements since the
+// last Reset.
+func (d *Duration) Average() time.Duration {
+	if d.iterations == 0 {
+		return 0
+	}
+	return d.durationSum / time.Duration(d.iterations)
+}
+
+// Reset discards all measurements.
+func (d *Duration) Reset() {
+	d.iterations = 0
+	d.durationSum = 0
+}
+
 func (d *Duration) Print(skips int) {
 	if d.iterations%skips == 0 {
 		durationAvgSec := d.durationSum.Seconds() / float64(d.iterations)
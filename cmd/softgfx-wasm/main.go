@@ -5,8 +5,8 @@ import (
 	"time"
 
 	"github.com/mokiat/softgfx/cmd/softgfx-wasm/internal/game"
-	"github.com/mokiat/softgfx/cmd/softgfx-wasm/internal/graphics"
 	"github.com/mokiat/softgfx/cmd/softgfx-wasm/internal/input"
+	"github.com/mokiat/softgfx/pkg/render/graphics"
 )
 
 func main() {
@@ -16,7 +16,7 @@ func main() {
 	}
 	defer keyboard.Destroy()
 
-	plotter, err := graphics.NewPlotter("screen")
+	plotter, err := graphics.NewCanvasPlotter("screen")
 	if err != nil {
 		panic(fmt.Errorf("could not create plotter: %s", err))
 	}
@@ -0,0 +1,159 @@
+// Package data defines the file formats of softgfx levels and textures.
+package data
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+func SaveLevel(out io.Writer, level Level) error {
+	if err := json.NewEncoder(out).Encode(level); err != nil {
+		return fmt.Errorf("failed to encode json: %w", err)
+	}
+	return nil
+}
+
+func LoadLevel(in io.Reader) (Level, error) {
+	var level Level
+	if err := json.NewDecoder(in).Decode(&level); err != nil {
+		return Level{}, fmt.Errorf("failed to decode json: %w", err)
+	}
+	return level, nil
+}
+
+type Level struct {
+	Textures []string `json:"textures"`
+	Walls    []Wall   `json:"walls"`
+	Start    *Entity  `json:"start,omitempty"`
+	Entities []Entity `json:"entities,omitempty"`
+
+	// Tint, if set, is the color of the light in the level.
+	// Surfaces are multiplied by it.
+	Tint *Color `json:"tint,omitempty"`
+
+	// Fog, if set, is the color towards which distant and dark surfaces
+	// fade. If omitted, they fade to black.
+	Fog *Color `json:"fog,omitempty"`
+
+	// Animations specify textures that change over time.
+	Animations []Animation `json:"animations,omitempty"`
+}
+
+// Animation makes a texture of the level change over time, by cycling
+// it through a number of frames, by scrolling it, or both.
+type Animation struct {
+	// Texture is the index of the texture that is animated.
+	Texture int `json:"texture"`
+
+	// Frames holds the indices of the textures that are shown in place
+	// of the animated one, in order. If omitted, it is not cycled.
+	Frames []int `json:"frames,omitempty"`
+
+	// FrameRate specifies how many frames are shown per second.
+	FrameRate float32 `json:"fps,omitempty"`
+
+	// ScrollU and ScrollV specify the speed, in texels per second,
+	// at which the texture scrolls.
+	ScrollU float32 `json:"su,omitempty"`
+	ScrollV float32 `json:"sv,omitempty"`
+}
+
+type Wall struct {
+	LeftEdgeX  float32 `json:"lx"`
+	LeftEdgeZ  float32 `json:"lz"`
+	RightEdgeX float32 `json:"rx"`
+	RightEdgeZ float32 `json:"rz"`
+
+	// OffsetU is the distance along the line of the wall from the origin
+	// of the U texture coordinate to the left edge of the wall. It keeps
+	// textures continuous across walls that were split from a longer one.
+	OffsetU float32 `json:"ou,omitempty"`
+
+	Ceiling *Extrusion `json:"c,omitempty"`
+	Floor   *Extrusion `json:"f,omitempty"`
+	Middle  *Middle    `json:"m,omitempty"`
+
+	FrontWall int `json:"fw"`
+	BackWall  int `json:"bw"`
+}
+
+type Extrusion struct {
+	Top    float32 `json:"t"`
+	Bottom float32 `json:"b"`
+
+	OuterTexture int `json:"ot"`
+	FaceTexture  int `json:"ft"`
+	InnerTexture int `json:"it"`
+
+	// OuterLight, FaceLight and InnerLight specify the light levels of
+	// the respective sides of the extrusion, ranging from 0.0 (black)
+	// to 1.0 (fully lit). If omitted, DefaultLight is used.
+	OuterLight *float32 `json:"ol,omitempty"`
+	FaceLight  *float32 `json:"fl,omitempty"`
+	InnerLight *float32 `json:"il,omitempty"`
+
+	// OuterMapping, FaceMapping and InnerMapping specify how the textures
+	// of the respective sides of the extrusion are aligned. If omitted,
+	// each world unit maps to a single texel.
+	OuterMapping *TextureMapping `json:"om,omitempty"`
+	FaceMapping  *TextureMapping `json:"fm,omitempty"`
+	InnerMapping *TextureMapping `json:"im,omitempty"`
+
+	// Sky specifies that the outer side of the extrusion shows the sky.
+	// Its texture is drawn as a panorama that depends only on the
+	// direction of the camera and its outer mapping is ignored.
+	Sky bool `json:"s,omitempty"`
+
+	// Mirror specifies that the face of the extrusion reflects the level.
+	// Its face texture is shown instead where the reflection cannot be
+	// rendered, such as in mirrors that face each other.
+	Mirror bool `json:"m,omitempty"`
+
+	// Tint and Fog, if set, override the respective colors of the level
+	// for all sides of the extrusion, which allows a region to have its
+	// own lighting.
+	Tint *Color `json:"tn,omitempty"`
+	Fog  *Color `json:"fg,omitempty"`
+}
+
+// TextureMapping specifies how a texture is aligned on a surface.
+// Texture coordinates, measured in texels, are equal to the world
+// coordinates multiplied by the scale, plus the offset. For walls, the
+// U world coordinate is the distance along the wall from its left edge
+// and the V world coordinate is the height. For floors and ceilings,
+// these are the X and Z world coordinates respectively.
+type TextureMapping struct {
+	OffsetU float32 `json:"ou"`
+	OffsetV float32 `json:"ov"`
+	ScaleU  float32 `json:"su"`
+	ScaleV  float32 `json:"sv"`
+}
+
+// DefaultLight is the light level of surfaces that do not specify one.
+const DefaultLight float32 = 1.0
+
+// Middle represents a texture that spans the gap between the ceiling and
+// the floor extrusion of a wall. Its texels may be transparent or
+// translucent, which allows for windows, fences, grates and glass.
+// If the wall has only one of the extrusions, the texture extends
+// from it by the height of the texture.
+type Middle struct {
+	Texture int             `json:"t"`
+	Light   *float32        `json:"l,omitempty"`
+	Mapping *TextureMapping `json:"mp,omitempty"`
+}
+
+// EntityTypeSpawn is the type of the entity that marks the player start.
+const EntityTypeSpawn = "spawn"
+
+// Entity represents an object that is placed in the level.
+// The Y coordinate of an entity specifies the height of its base.
+type Entity struct {
+	Type       string            `json:"type"`
+	X          float32           `json:"x"`
+	Y          float32           `json:"y"`
+	Z          float32           `json:"z"`
+	Angle      float32           `json:"a"`
+	Properties map[string]string `json:"props,omitempty"`
+}
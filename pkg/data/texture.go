@@ -0,0 +1,111 @@
+package data
+
+import (
+	"fmt"
+	"image/png"
+	"io"
+)
+
+func LoadTexture(in io.Reader) (Texture, error) {
+	img, err := png.Decode(in)
+	if err != nil {
+		return Texture{}, fmt.Errorf("failed to decode png image: %w", err)
+	}
+
+	size := img.Bounds().Size()
+	width := size.X
+	height := size.Y
+	if !isPowerOfTwo(width) || !isPowerOfTwo(height) {
+		return Texture{}, fmt.Errorf("image size %dx%d is not a power of two", width, height)
+	}
+	texels := make([]byte, width*height*4)
+
+	offset := 0
+	for x := 0; x < width; x++ {
+		for y := 0; y < height; y++ {
+			r, g, b, a := img.At(x, y).RGBA()
+			texels[offset+0] = byte(r >> 8)
+			texels[offset+1] = byte(g >> 8)
+			texels[offset+2] = byte(b >> 8)
+			texels[offset+3] = byte(a >> 8)
+			offset += 4
+		}
+	}
+
+	texture := Texture{
+		Width:  width,
+		Height: height,
+		Texels: texels,
+	}
+	texture.Mipmaps = buildMipmaps(texture)
+	return texture, nil
+}
+
+// buildMipmaps creates the mip chain of the specified texture. Each
+// mipmap is half the size of the previous one, with the last one being
+// of size 1x1. Every texel of a mipmap is the average of the texels that
+// it covers in the previous one.
+func buildMipmaps(texture Texture) []Texture {
+	var mipmaps []Texture
+	previous := texture
+	for (previous.Width > 1) || (previous.Height > 1) {
+		width := maxInt(previous.Width/2, 1)
+		height := maxInt(previous.Height/2, 1)
+		scaleX := previous.Width / width
+		scaleY := previous.Height / height
+
+		texels := make([]byte, width*height*4)
+		offset := 0
+		for x := 0; x < width; x++ {
+			for y := 0; y < height; y++ {
+				for channel := 0; channel < 4; channel++ {
+					sum := 0
+					for dx := 0; dx < scaleX; dx++ {
+						for dy := 0; dy < scaleY; dy++ {
+							previousOffset := ((x*scaleX+dx)*previous.Height + (y*scaleY + dy)) * 4
+							sum += int(previous.Texels[previousOffset+channel])
+						}
+					}
+					texels[offset+channel] = byte(sum / (scaleX * scaleY))
+				}
+				offset += 4
+			}
+		}
+
+		mipmap := Texture{
+			Width:  width,
+			Height: height,
+			Texels: texels,
+		}
+		mipmaps = append(mipmaps, mipmap)
+		previous = mipmap
+	}
+	return mipmaps
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+type Texture struct {
+	Width  int
+	Height int
+	Texels []byte
+
+	// Mipmaps holds progressively downscaled versions of the texture,
+	// starting with the one that is half the size of the texture.
+	Mipmaps []Texture
+}
+
+func isPowerOfTwo(value int) bool {
+	return (value > 0) && (value&(value-1) == 0)
+}
+
+type Color struct {
+	R byte `json:"r"`
+	G byte `json:"g"`
+	B byte `json:"b"`
+}
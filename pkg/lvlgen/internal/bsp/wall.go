@@ -1,6 +1,9 @@
 package bsp
 
-import "github.com/mokiat/gomath/dprec"
+import (
+	"github.com/mokiat/gomath/dprec"
+	"github.com/mokiat/softgfx/pkg/lvlgen/internal/scene"
+)
 
 type Extrusion struct {
 	Top    float64
@@ -9,6 +12,10 @@ type Extrusion struct {
 	OuterTextureName string
 	FaceTextureName  string
 	InnerTextureName string
+
+	OuterMapping *scene.UVMapping
+	FaceMapping  *scene.UVMapping
+	InnerMapping *scene.UVMapping
 }
 
 type Wall struct {
@@ -17,6 +24,11 @@ type Wall struct {
 	RightX float64
 	RightZ float64
 
+	// OffsetU is the distance along the line of the wall from the left
+	// edge of the original wall to the left edge of this one. It is
+	// non-zero only for walls that are the result of a split.
+	OffsetU float64
+
 	Ceiling *Extrusion
 	Floor   *Extrusion
 
@@ -107,6 +119,7 @@ func (w *Wall) Split(separator *Wall) (*Wall, *Wall) {
 		LeftZ:   w.LeftZ,
 		RightX:  w.LeftX*rightRatio + w.RightX*leftRatio,
 		RightZ:  w.LeftZ*rightRatio + w.RightZ*leftRatio,
+		OffsetU: w.OffsetU,
 		Ceiling: w.Ceiling,
 		Floor:   w.Floor,
 	}
@@ -118,6 +131,7 @@ func (w *Wall) Split(separator *Wall) (*Wall, *Wall) {
 		Ceiling: w.Ceiling,
 		Floor:   w.Floor,
 	}
+	right.OffsetU = w.OffsetU + dprec.Vec3Diff(right.FlatLeft(), w.FlatLeft()).Length()
 	if leftDistance < rightDistance {
 		return right, left
 	}
@@ -0,0 +1,102 @@
+package objutil
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/mokiat/go-data-front/decoder/obj"
+	"github.com/mokiat/gomath/dprec"
+)
+
+const (
+	spawnMarkerName    = "spawn"
+	entityMarkerPrefix = "entity:"
+)
+
+// blenderSuffixPattern matches the numeric suffix that Blender appends
+// to duplicated object names (e.g. `spawn.001`).
+var blenderSuffixPattern = regexp.MustCompile(`\.[0-9]+$`)
+
+// Marker represents an object of the model that does not describe level
+// geometry but instead marks the placement of an entity.
+type Marker struct {
+	Type       string
+	Properties map[string]string
+	Position   dprec.Vec3
+}
+
+// ParseMarkerName parses object names of the form `spawn[:key=value...]`
+// and `entity:type[:key=value...]` and returns the marker type and
+// properties. The last return value is false if the name does not
+// denote a marker.
+func ParseMarkerName(name string) (string, map[string]string, bool) {
+	name = blenderSuffixPattern.ReplaceAllString(name, "")
+
+	var parts []string
+	switch {
+	case name == spawnMarkerName || strings.HasPrefix(name, spawnMarkerName+":"):
+		parts = strings.Split(name, ":")
+	case strings.HasPrefix(name, entityMarkerPrefix):
+		parts = strings.Split(strings.TrimPrefix(name, entityMarkerPrefix), ":")
+	default:
+		return "", nil, false
+	}
+	if parts[0] == "" {
+		return "", nil, false
+	}
+
+	properties := make(map[string]string)
+	for _, part := range parts[1:] {
+		key, value, _ := strings.Cut(part, "=")
+		properties[key] = value
+	}
+	return parts[0], properties, true
+}
+
+func isMarker(object *obj.Object) bool {
+	_, _, ok := ParseMarkerName(object.Name)
+	return ok
+}
+
+// Markers returns all marker objects in the model. The position of a
+// marker is the bottom center of the bounding box of its vertices.
+func (w ModelWrapper) Markers() []Marker {
+	var result []Marker
+	for _, object := range w.model.Objects {
+		markerType, properties, ok := ParseMarkerName(object.Name)
+		if !ok {
+			continue
+		}
+
+		var (
+			minBounds dprec.Vec3
+			maxBounds dprec.Vec3
+			hasBounds bool
+		)
+		for _, mesh := range object.Meshes {
+			for _, face := range mesh.Faces {
+				for _, reference := range face.References {
+					vertex := w.model.GetVertexFromReference(reference)
+					point := dprec.NewVec3(vertex.X, vertex.Y, vertex.Z)
+					if !hasBounds {
+						minBounds, maxBounds, hasBounds = point, point, true
+						continue
+					}
+					minBounds = dprec.NewVec3(dprec.Min(minBounds.X, point.X), dprec.Min(minBounds.Y, point.Y), dprec.Min(minBounds.Z, point.Z))
+					maxBounds = dprec.NewVec3(dprec.Max(maxBounds.X, point.X), dprec.Max(maxBounds.Y, point.Y), dprec.Max(maxBounds.Z, point.Z))
+				}
+			}
+		}
+
+		result = append(result, Marker{
+			Type:       markerType,
+			Properties: properties,
+			Position: dprec.NewVec3(
+				(minBounds.X+maxBounds.X)/2.0,
+				minBounds.Y,
+				(minBounds.Z+maxBounds.Z)/2.0,
+			),
+		})
+	}
+	return result
+}
@@ -1,8 +1,6 @@
 package objutil
 
 import (
-	"log"
-
 	"github.com/mokiat/go-data-front/decoder/obj"
 	"github.com/mokiat/gomath/dprec"
 )
@@ -12,6 +10,13 @@ type Triangle struct {
 	P2           dprec.Vec3
 	P3           dprec.Vec3
 	MaterialName string
+
+	// HasTexCoords indicates whether the UV fields hold the texture
+	// coordinates of the respective vertices.
+	HasTexCoords bool
+	UV1          dprec.Vec2
+	UV2          dprec.Vec2
+	UV3          dprec.Vec2
 }
 
 type Line struct {
@@ -45,6 +50,9 @@ func (w ModelWrapper) Meshes() <-chan *obj.Mesh {
 	result := make(chan *obj.Mesh)
 	go func() {
 		for _, obj := range w.model.Objects {
+			if isMarker(obj) {
+				continue
+			}
 			for _, mesh := range obj.Meshes {
 				result <- mesh
 			}
@@ -54,6 +62,20 @@ func (w ModelWrapper) Meshes() <-chan *obj.Mesh {
 	return result
 }
 
+// IncompleteFaces returns the number of faces that have fewer than three
+// vertices, which are skipped by Triangles.
+func (w ModelWrapper) IncompleteFaces() int {
+	count := 0
+	for mesh := range w.Meshes() {
+		for _, face := range mesh.Faces {
+			if len(face.References) < 3 {
+				count++
+			}
+		}
+	}
+	return count
+}
+
 func (w ModelWrapper) Triangles() <-chan Triangle {
 	result := make(chan Triangle)
 	go func() {
@@ -61,20 +83,31 @@ func (w ModelWrapper) Triangles() <-chan Triangle {
 			for _, face := range mesh.Faces {
 				vertexCount := len(face.References)
 				if vertexCount < 3 {
-					log.Printf("warning: skipping face: insufficient number of vertices: %d\n", vertexCount)
 					continue
 				}
 
+				hasTexCoords := true
+				for _, reference := range face.References {
+					hasTexCoords = hasTexCoords && reference.HasTexCoord()
+				}
+
 				vertex1 := w.model.GetVertexFromReference(face.References[0])
 				vertex2 := w.model.GetVertexFromReference(face.References[1])
 				for i := 2; i < vertexCount; i++ {
 					vertex3 := w.model.GetVertexFromReference(face.References[i])
-					result <- Triangle{
+					triangle := Triangle{
 						P1:           dprec.NewVec3(vertex1.X, vertex1.Y, vertex1.Z),
 						P2:           dprec.NewVec3(vertex2.X, vertex2.Y, vertex2.Z),
 						P3:           dprec.NewVec3(vertex3.X, vertex3.Y, vertex3.Z),
 						MaterialName: mesh.MaterialName,
+						HasTexCoords: hasTexCoords,
 					}
+					if hasTexCoords {
+						triangle.UV1 = w.texCoord(face.References[0])
+						triangle.UV2 = w.texCoord(face.References[i-1])
+						triangle.UV3 = w.texCoord(face.References[i])
+					}
+					result <- triangle
 					vertex2 = vertex3
 				}
 			}
@@ -84,6 +117,11 @@ func (w ModelWrapper) Triangles() <-chan Triangle {
 	return result
 }
 
+func (w ModelWrapper) texCoord(reference obj.Reference) dprec.Vec2 {
+	texCoord := w.model.GetTexCoordFromReference(reference)
+	return dprec.NewVec2(texCoord.U, texCoord.V)
+}
+
 func (w ModelWrapper) Edges() <-chan Line {
 	result := make(chan Line)
 	go func() {
@@ -10,6 +10,7 @@ type Span struct {
 	Top         float64
 	Bottom      float64
 	TextureName string
+	Mapping     *UVMapping
 }
 
 func (s Span) IsContinuationTo(other Span, precision float64) bool {
@@ -0,0 +1,51 @@
+package scene
+
+import "github.com/mokiat/gomath/dprec"
+
+// UVMapping is an affine function that maps points on a surface to OBJ
+// texture coordinates, where (0, 0) is the bottom left corner of the
+// texture and (1, 1) is the top right one.
+type UVMapping struct {
+	Origin    dprec.Vec3
+	OriginUV  dprec.Vec2
+	GradientU dprec.Vec3
+	GradientV dprec.Vec3
+}
+
+// NewUVMapping creates the UVMapping of the triangle with the specified
+// vertices and texture coordinates. If the triangle is degenerate, nil
+// is returned.
+func NewUVMapping(p1, p2, p3 dprec.Vec3, uv1, uv2, uv3 dprec.Vec2) *UVMapping {
+	edge1 := dprec.Vec3Diff(p2, p1)
+	edge2 := dprec.Vec3Diff(p3, p1)
+	dot11 := dprec.Vec3Dot(edge1, edge1)
+	dot12 := dprec.Vec3Dot(edge1, edge2)
+	dot22 := dprec.Vec3Dot(edge2, edge2)
+	determinant := dot11*dot22 - dot12*dot12
+	if dprec.Abs(determinant) < 0.000001 {
+		return nil
+	}
+
+	// The gradients lie in the plane of the triangle, which makes them
+	// a linear combination of the two edges.
+	gradient := func(delta1, delta2 float64) dprec.Vec3 {
+		alpha := (delta1*dot22 - delta2*dot12) / determinant
+		beta := (delta2*dot11 - delta1*dot12) / determinant
+		return dprec.Vec3Sum(dprec.Vec3Prod(edge1, alpha), dprec.Vec3Prod(edge2, beta))
+	}
+	return &UVMapping{
+		Origin:    p1,
+		OriginUV:  uv1,
+		GradientU: gradient(uv2.X-uv1.X, uv3.X-uv1.X),
+		GradientV: gradient(uv2.Y-uv1.Y, uv3.Y-uv1.Y),
+	}
+}
+
+// UV returns the texture coordinates at the specified point.
+func (m *UVMapping) UV(point dprec.Vec3) dprec.Vec2 {
+	offset := dprec.Vec3Diff(point, m.Origin)
+	return dprec.NewVec2(
+		m.OriginUV.X+dprec.Vec3Dot(m.GradientU, offset),
+		m.OriginUV.Y+dprec.Vec3Dot(m.GradientV, offset),
+	)
+}
@@ -8,6 +8,7 @@ type Segment struct {
 	Normal      dprec.Vec3
 	Lines       []Line
 	TextureName string
+	Mapping     *UVMapping
 }
 
 func (s Segment) Middle() VerticalLine {
@@ -81,6 +82,7 @@ func (s Segment) LeftPartition(verticalLine VerticalLine, precision float64) Seg
 		Normal:      s.Normal,
 		Lines:       partitionedLines,
 		TextureName: s.TextureName,
+		Mapping:     s.Mapping,
 	}
 }
 
@@ -107,6 +109,7 @@ func (s Segment) RightPartition(verticalLine VerticalLine, precision float64) Se
 		Normal:      s.Normal,
 		Lines:       partitionedLines,
 		TextureName: s.TextureName,
+		Mapping:     s.Mapping,
 	}
 }
 
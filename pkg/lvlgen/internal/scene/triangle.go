@@ -7,6 +7,7 @@ type Triangle struct {
 	P2          dprec.Vec3
 	P3          dprec.Vec3
 	TextureName string
+	Mapping     *UVMapping
 }
 
 func (t Triangle) Line1() Line {
@@ -0,0 +1,727 @@
+// Package lvlgen converts Wavefront OBJ models into softgfx levels.
+//
+// Objects with special names are not converted into geometry but into
+// entities instead. An object named `spawn` marks the player start and
+// objects named `entity:<type>` mark entities of the given type.
+// Both can be followed by properties in the form `:key=value`, where the
+// `angle` property specifies the rotation of the entity in degrees.
+// For example: `entity:torch:angle=90:color=red`.
+//
+// Material names specify the texture of a surface. They can be followed
+// by a light level in the form `@<light>`, ranging from 0.0 (black) to
+// 1.0 (fully lit). For example: `wall-bricks@0.4`.
+//
+// Ceilings and floors whose texture is configured through WithSkyTextures
+// show the sky instead of a flat surface. Similarly, wall faces whose
+// texture is configured through WithMirrorTextures reflect the level.
+//
+// The texture coordinates of the model are used to align the textures.
+// Since textures are loaded only at runtime, all of them are assumed to
+// be of the size that is configured through WithTextureSize.
+//
+// The progress of the conversion and any problems with the model, which
+// do not prevent it from being converted, are reported to the logger that
+// is configured through WithLogger.
+package lvlgen
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/mokiat/go-data-front/decoder/obj"
+	"github.com/mokiat/gomath/dprec"
+	"github.com/mokiat/softgfx/pkg/data"
+	"github.com/mokiat/softgfx/pkg/lvlgen/internal/bsp"
+	"github.com/mokiat/softgfx/pkg/lvlgen/internal/objutil"
+	"github.com/mokiat/softgfx/pkg/lvlgen/internal/scene"
+)
+
+const (
+	precision = 0.001
+
+	// DefaultScale is the factor by which models are scaled, unless
+	// configured otherwise through WithScale.
+	DefaultScale = 64.0
+
+	// DefaultTextureSize is the assumed size of textures, unless
+	// configured otherwise through WithTextureSize.
+	DefaultTextureSize = 64.0
+)
+
+type config struct {
+	scale          float64
+	textureSize    float64
+	skyTextures    []string
+	mirrorTextures []string
+	logger         *log.Logger
+}
+
+// Option configures the level generation process.
+type Option func(c *config)
+
+// WithScale configures the factor by which the model is scaled
+// before it is converted into a level.
+func WithScale(scale float64) Option {
+	return func(c *config) {
+		c.scale = scale
+	}
+}
+
+// WithTextureSize configures the size of the textures, which is needed
+// to convert the texture coordinates of the model into texel offsets.
+func WithTextureSize(size float64) Option {
+	return func(c *config) {
+		c.textureSize = size
+	}
+}
+
+// WithSkyTextures configures the names of the textures that show the sky
+// when they are used on ceilings or floors.
+func WithSkyTextures(names ...string) Option {
+	return func(c *config) {
+		c.skyTextures = names
+	}
+}
+
+// WithMirrorTextures configures the names of the textures that reflect
+// the level when they are used on wall faces. The textures are still
+// shown where the reflection cannot be rendered.
+func WithMirrorTextures(names ...string) Option {
+	return func(c *config) {
+		c.mirrorTextures = names
+	}
+}
+
+// WithLogger configures the logger to which the progress of the
+// conversion and any warnings about the model are written. By default,
+// nothing is logged.
+func WithLogger(logger *log.Logger) Option {
+	return func(c *config) {
+		c.logger = logger
+	}
+}
+
+// Convert reads a Wavefront OBJ model from in, converts it into a level
+// and writes the level in json format to out.
+func Convert(in io.Reader, out io.Writer, opts ...Option) error {
+	level, err := Generate(in, opts...)
+	if err != nil {
+		return err
+	}
+	if err := data.SaveLevel(out, level); err != nil {
+		return fmt.Errorf("failed to save level: %w", err)
+	}
+	return nil
+}
+
+// Generate reads a Wavefront OBJ model from in and converts it into a level.
+func Generate(in io.Reader, opts ...Option) (data.Level, error) {
+	cfg := config{
+		scale:       DefaultScale,
+		textureSize: DefaultTextureSize,
+		logger:      log.New(io.Discard, "", 0),
+	}
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+	logger := cfg.logger
+
+	decoder := obj.NewDecoder(obj.DefaultLimits())
+	model, err := decoder.Decode(in)
+	if err != nil {
+		return data.Level{}, fmt.Errorf("failed to decode obj file: %w", err)
+	}
+
+	logger.Printf("scaling model (factor: %f)...\n", cfg.scale)
+	objutil.Model(model).Scale(cfg.scale)
+	if count := objutil.Model(model).IncompleteFaces(); count > 0 {
+		logger.Printf("warning: skipping %d faces: insufficient number of vertices\n", count)
+	}
+
+	logger.Println("extracting entities...")
+	start, entities := extractEntities(model, logger)
+	logger.Printf("\tfound: %d\n", len(entities))
+	if start == nil {
+		logger.Println("warning: no spawn object found")
+	}
+
+	logger.Println("extracting vertical lines...")
+	verticalLines := extractVerticalLines(model)
+	logger.Printf("\tfound: %d\n", len(verticalLines))
+	verticalLines = verticalLines.Dedupe(precision)
+	logger.Printf("\tunique: %d\n", len(verticalLines))
+
+	logger.Println("extracting floor triangles...")
+	floorTriangles := extractFloorTriangles(model)
+	logger.Printf("\tfound: %d\n", len(floorTriangles))
+
+	logger.Println("extracting ceiling triangles...")
+	ceilingTriangles := extractCeilingTriangles(model)
+	logger.Printf("\tfound: %d\n", len(ceilingTriangles))
+
+	logger.Println("extracting vertical triangles...")
+	verticalTriangles := extractVerticalTriangles(model)
+	logger.Printf("\tfound: %d\n", len(verticalTriangles))
+
+	logger.Println("building segments...")
+	segments := buildSegments(verticalTriangles)
+	logger.Printf("\ttotal: %d\n", len(segments))
+	segments = partitionSegments(segments, verticalLines)
+	logger.Printf("\tpartitioned: %d\n", len(segments))
+
+	logger.Println("building blocks...")
+	blocks := buildBlocks(segments, logger)
+	logger.Printf("\ttotal: %d\n", len(blocks))
+	blocks = blocks.Merge(precision)
+	logger.Printf("\tmerged: %d\n", len(blocks))
+
+	logger.Println("building walls...")
+	walls := buildWalls(blocks, floorTriangles, ceilingTriangles, logger)
+	logger.Printf("\ttotal: %d\n", len(walls))
+
+	logger.Println("partitioning walls...")
+	tree := bsp.Partition(walls, precision)
+	logger.Printf("\ttotal: %d\n", tree.Count())
+
+	level := buildLevel(tree, cfg)
+	level.Start = start
+	level.Entities = entities
+	return level, nil
+}
+
+// extractEntities converts the marker objects of the model into entities.
+// The spawn marker is returned separately as the player start.
+func extractEntities(model *obj.Model, logger *log.Logger) (*data.Entity, []data.Entity) {
+	var (
+		start    *data.Entity
+		entities []data.Entity
+	)
+	for _, marker := range objutil.Model(model).Markers() {
+		entity := data.Entity{
+			Type: marker.Type,
+			X:    float32(marker.Position.X),
+			Y:    -float32(marker.Position.Y),
+			Z:    -float32(marker.Position.Z),
+		}
+		for key, value := range marker.Properties {
+			if key == "angle" {
+				angle, err := strconv.ParseFloat(value, 32)
+				if err != nil {
+					logger.Printf("warning: ignoring invalid angle %q of %q entity\n", value, marker.Type)
+					continue
+				}
+				entity.Angle = float32(angle)
+				continue
+			}
+			if entity.Properties == nil {
+				entity.Properties = make(map[string]string)
+			}
+			entity.Properties[key] = value
+		}
+
+		if entity.Type == data.EntityTypeSpawn {
+			if start != nil {
+				logger.Println("warning: ignoring duplicate spawn object")
+				continue
+			}
+			start = &entity
+			continue
+		}
+		entities = append(entities, entity)
+	}
+	return start, entities
+}
+
+func extractVerticalLines(model *obj.Model) scene.VerticalLineList {
+	var result scene.VerticalLineList
+	for objLine := range objutil.Model(model).Edges() {
+		if !objLine.Vertical(precision) {
+			continue
+		}
+		result = append(result, scene.VerticalLine{
+			X: objLine.P1.X,
+			Z: objLine.P1.Z,
+		})
+	}
+	return result
+}
+
+func extractFloorTriangles(model *obj.Model) scene.TriangleList {
+	var result scene.TriangleList
+	for objTriangle := range objutil.Model(model).Triangles() {
+		sceneTriangle := scene.Triangle{
+			P1:          objTriangle.P1,
+			P2:          objTriangle.P2,
+			P3:          objTriangle.P3,
+			TextureName: objTriangle.MaterialName,
+			Mapping:     triangleMapping(objTriangle),
+		}
+		if sceneTriangle.IsFloor(precision) {
+			result = append(result, sceneTriangle)
+		}
+	}
+	return result
+}
+
+func extractCeilingTriangles(model *obj.Model) scene.TriangleList {
+	var result scene.TriangleList
+	for objTriangle := range objutil.Model(model).Triangles() {
+		sceneTriangle := scene.Triangle{
+			P1:          objTriangle.P1,
+			P2:          objTriangle.P2,
+			P3:          objTriangle.P3,
+			TextureName: objTriangle.MaterialName,
+			Mapping:     triangleMapping(objTriangle),
+		}
+		if sceneTriangle.IsCeiling(precision) {
+			result = append(result, sceneTriangle)
+		}
+	}
+	return result
+}
+
+func extractVerticalTriangles(model *obj.Model) scene.TriangleList {
+	var result scene.TriangleList
+	for objTriangle := range objutil.Model(model).Triangles() {
+		sceneTriangle := scene.Triangle{
+			P1:          objTriangle.P1,
+			P2:          objTriangle.P2,
+			P3:          objTriangle.P3,
+			TextureName: objTriangle.MaterialName,
+			Mapping:     triangleMapping(objTriangle),
+		}
+		if sceneTriangle.IsVertical(precision) {
+			result = append(result, sceneTriangle)
+		}
+	}
+	return result
+}
+
+func triangleMapping(triangle objutil.Triangle) *scene.UVMapping {
+	if !triangle.HasTexCoords {
+		return nil
+	}
+	return scene.NewUVMapping(
+		triangle.P1, triangle.P2, triangle.P3,
+		triangle.UV1, triangle.UV2, triangle.UV3,
+	)
+}
+
+func buildSegments(verticalTriangles scene.TriangleList) scene.SegmentList {
+	result := make(scene.SegmentList, len(verticalTriangles))
+	for i, triangle := range verticalTriangles {
+		result[i] = scene.Segment{
+			Left:   triangle.Left(),
+			Right:  triangle.Right(),
+			Normal: triangle.Normal(),
+			Lines: []scene.Line{
+				triangle.Line1(),
+				triangle.Line2(),
+				triangle.Line3(),
+			},
+			TextureName: triangle.TextureName,
+			Mapping:     triangle.Mapping,
+		}
+	}
+	return result
+}
+
+func partitionSegments(segments scene.SegmentList, verticalLines scene.VerticalLineList) scene.SegmentList {
+	var result scene.SegmentList
+	for _, segment := range segments {
+		subSegments := partitionSegment(segment, verticalLines)
+		result = append(result, subSegments...)
+	}
+	return result
+}
+
+func partitionSegment(segment scene.Segment, verticalLines scene.VerticalLineList) scene.SegmentList {
+	for i, verticalLine := range verticalLines {
+		isPartitioned :=
+			!verticalLine.Equal(segment.Left, precision) &&
+				!verticalLine.Equal(segment.Right, precision) &&
+				segment.ContainsVerticalLine(verticalLine, precision)
+
+		if isPartitioned {
+			leftSegment := segment.LeftPartition(verticalLine, precision)
+			leftSubSegments := partitionSegment(leftSegment, verticalLines[i+1:])
+
+			rightSegment := segment.RightPartition(verticalLine, precision)
+			rightSubSegments := partitionSegment(rightSegment, verticalLines[i+1:])
+
+			return append(leftSubSegments, rightSubSegments...)
+		}
+	}
+
+	return scene.SegmentList{segment}
+}
+
+func buildBlocks(segments scene.SegmentList, logger *log.Logger) scene.BlockList {
+	var result scene.BlockList
+	for _, segment := range segments {
+		if len(segment.Lines) == 0 {
+			logger.Println("warning: skipping segment: no lines present")
+			continue
+		}
+		result = append(result, scene.Block{
+			Left:   segment.Left,
+			Right:  segment.Right,
+			Normal: segment.Normal,
+			Spans: []scene.Span{
+				scene.Span{
+					Top:         segment.Top(),
+					Bottom:      segment.Bottom(),
+					TextureName: segment.TextureName,
+					Mapping:     segment.Mapping,
+				},
+			},
+		})
+	}
+	return result
+}
+
+func buildWalls(blocks scene.BlockList, floorTriangles, ceilingTriangles scene.TriangleList, logger *log.Logger) []*bsp.Wall {
+	var walls []*bsp.Wall
+	for _, block := range blocks {
+		wall, err := buildWall(block, floorTriangles, ceilingTriangles)
+		if err != nil {
+			logger.Printf("warning: skipping block: %v\n", err)
+			continue
+		}
+		walls = append(walls, wall)
+	}
+	return walls
+}
+
+func buildWall(block scene.Block, floorTriangles, ceilingTriangles scene.TriangleList) (*bsp.Wall, error) {
+	wall := &bsp.Wall{
+		LeftX:  block.Left.X,
+		LeftZ:  block.Left.Z,
+		RightX: block.Right.X,
+		RightZ: block.Right.Z,
+	}
+
+	switch spanCount := len(block.Spans); spanCount {
+	case 2:
+		// it is a split wall
+		outerCeiling, outerCeilingFound := findHorizontalTriangle(ceilingTriangles, spanMiddleTop(block, block.Spans[0]))
+		innerCeiling, innerCeilingFound := findHorizontalTriangle(ceilingTriangles, spanMiddleBottom(block, block.Spans[0]))
+		innerFloor, innerFloorFound := findHorizontalTriangle(floorTriangles, spanMiddleTop(block, block.Spans[1]))
+		outerFloor, outerFloorFound := findHorizontalTriangle(floorTriangles, spanMiddleBottom(block, block.Spans[1]))
+		if !outerCeilingFound || !innerCeilingFound || !innerFloorFound || !outerFloorFound {
+			return nil, fmt.Errorf("could not find all floors and ceilings for block")
+		}
+		wall.Ceiling = &bsp.Extrusion{
+			Top:              block.Spans[0].Top,
+			Bottom:           block.Spans[0].Bottom,
+			OuterTextureName: outerCeiling.TextureName,
+			FaceTextureName:  block.Spans[0].TextureName,
+			InnerTextureName: innerCeiling.TextureName,
+			OuterMapping:     outerCeiling.Mapping,
+			FaceMapping:      block.Spans[0].Mapping,
+			InnerMapping:     innerCeiling.Mapping,
+		}
+		wall.Floor = &bsp.Extrusion{
+			Top:              block.Spans[1].Top,
+			Bottom:           block.Spans[1].Bottom,
+			InnerTextureName: innerFloor.TextureName,
+			FaceTextureName:  block.Spans[1].TextureName,
+			OuterTextureName: outerFloor.TextureName,
+			InnerMapping:     innerFloor.Mapping,
+			FaceMapping:      block.Spans[1].Mapping,
+			OuterMapping:     outerFloor.Mapping,
+		}
+		return wall, nil
+
+	case 1:
+		// it is a solid wall or a singular extrusion
+		outerCeiling, outerCeilingFound := findHorizontalTriangle(ceilingTriangles, spanMiddleTop(block, block.Spans[0]))
+		outerFloor, outerFloorFound := findHorizontalTriangle(floorTriangles, spanMiddleBottom(block, block.Spans[0]))
+
+		switch {
+		case outerCeilingFound && outerFloorFound:
+			// it is a solid wall top-to-bottom
+			wall.Ceiling = &bsp.Extrusion{
+				Top:              block.Spans[0].Top,
+				Bottom:           (block.Spans[0].Top + block.Spans[0].Bottom) / 2.0,
+				OuterTextureName: outerCeiling.TextureName,
+				FaceTextureName:  block.Spans[0].TextureName,
+				InnerTextureName: outerCeiling.TextureName, // irrelevant, but set to something valid
+				OuterMapping:     outerCeiling.Mapping,
+				FaceMapping:      block.Spans[0].Mapping,
+				InnerMapping:     outerCeiling.Mapping,
+			}
+			wall.Floor = &bsp.Extrusion{
+				Top:              (block.Spans[0].Top + block.Spans[0].Bottom) / 2.0,
+				Bottom:           block.Spans[0].Bottom,
+				InnerTextureName: outerFloor.TextureName, // irrelevant, but set to something valid
+				FaceTextureName:  block.Spans[0].TextureName,
+				OuterTextureName: outerFloor.TextureName,
+				InnerMapping:     outerFloor.Mapping,
+				FaceMapping:      block.Spans[0].Mapping,
+				OuterMapping:     outerFloor.Mapping,
+			}
+			return wall, nil
+
+		case outerCeilingFound:
+			// it is a ceiling wall extrusion
+			innerCeiling, innerCeilingFound := findHorizontalTriangle(ceilingTriangles, spanMiddleBottom(block, block.Spans[0]))
+			if !innerCeilingFound {
+				return nil, fmt.Errorf("could not find inner ceiling texture for ceiling extrusion")
+			}
+			wall.Ceiling = &bsp.Extrusion{
+				Top:              block.Spans[0].Top,
+				Bottom:           block.Spans[0].Bottom,
+				OuterTextureName: outerCeiling.TextureName,
+				FaceTextureName:  block.Spans[0].TextureName,
+				InnerTextureName: innerCeiling.TextureName,
+				OuterMapping:     outerCeiling.Mapping,
+				FaceMapping:      block.Spans[0].Mapping,
+				InnerMapping:     innerCeiling.Mapping,
+			}
+			return wall, nil
+
+		case outerFloorFound:
+			// it is a floor wall extrusion
+			innerFloor, innerFloorFound := findHorizontalTriangle(floorTriangles, spanMiddleTop(block, block.Spans[0]))
+			if !innerFloorFound {
+				return nil, fmt.Errorf("could not find inner floor texture for floor extrusion")
+			}
+			wall.Floor = &bsp.Extrusion{
+				Top:              block.Spans[0].Top,
+				Bottom:           block.Spans[0].Bottom,
+				InnerTextureName: innerFloor.TextureName,
+				FaceTextureName:  block.Spans[0].TextureName,
+				OuterTextureName: outerFloor.TextureName,
+				InnerMapping:     innerFloor.Mapping,
+				FaceMapping:      block.Spans[0].Mapping,
+				OuterMapping:     outerFloor.Mapping,
+			}
+			return wall, nil
+
+		default:
+			return nil, fmt.Errorf("could not find floor or ceiling texture for block")
+		}
+
+	default:
+		return nil, fmt.Errorf("unexpected span count: %d", spanCount)
+	}
+}
+
+func spanMiddleTop(block scene.Block, span scene.Span) dprec.Vec3 {
+	return dprec.Vec3{
+		X: (block.Left.X + block.Right.X) / 2.0,
+		Y: span.Top,
+		Z: (block.Left.Z + block.Right.Z) / 2.0,
+	}
+}
+
+func spanMiddleBottom(block scene.Block, span scene.Span) dprec.Vec3 {
+	return dprec.Vec3{
+		X: (block.Left.X + block.Right.X) / 2.0,
+		Y: span.Bottom,
+		Z: (block.Left.Z + block.Right.Z) / 2.0,
+	}
+}
+
+func findHorizontalTriangle(triangles scene.TriangleList, edgePoint dprec.Vec3) (scene.Triangle, bool) {
+	for _, triangle := range triangles {
+		if triangle.Line1().ContainsPoint(edgePoint, precision) {
+			return triangle, true
+		}
+		if triangle.Line2().ContainsPoint(edgePoint, precision) {
+			return triangle, true
+		}
+		if triangle.Line3().ContainsPoint(edgePoint, precision) {
+			return triangle, true
+		}
+	}
+	return scene.Triangle{}, false
+}
+
+func buildLevel(root *bsp.Wall, cfg config) data.Level {
+	textureSize := cfg.textureSize
+	jsonTextures := make([]string, 0)
+	jsonWalls := make([]data.Wall, 0, root.Count())
+
+	registerTexture := func(textureName string) int {
+		for i, jsonTexture := range jsonTextures {
+			if jsonTexture == textureName {
+				return i
+			}
+		}
+
+		jsonTextures = append(jsonTextures, textureName)
+		return len(jsonTextures) - 1
+	}
+
+	type material struct {
+		texture int
+		light   *float32
+		sky     bool
+		mirror  bool
+	}
+	materials := make(map[string]material)
+	getMaterial := func(materialName string) material {
+		if mat, ok := materials[materialName]; ok {
+			return mat
+		}
+		textureName, light := parseMaterialName(materialName, cfg.logger)
+		mat := material{
+			texture: registerTexture(textureName),
+			light:   light,
+		}
+		for _, skyTexture := range cfg.skyTextures {
+			if textureName == skyTexture {
+				mat.sky = true
+			}
+		}
+		for _, mirrorTexture := range cfg.mirrorTextures {
+			if textureName == mirrorTexture {
+				mat.mirror = true
+			}
+		}
+		materials[materialName] = mat
+		return mat
+	}
+	registerMaterial := func(materialName string) (int, *float32) {
+		mat := getMaterial(materialName)
+		return mat.texture, mat.light
+	}
+	isSkyMaterial := func(materialName string) bool {
+		return getMaterial(materialName).sky
+	}
+	isMirrorMaterial := func(materialName string) bool {
+		return getMaterial(materialName).mirror
+	}
+
+	var processWall func(wall *bsp.Wall) int
+	processWall = func(wall *bsp.Wall) int {
+		if wall == nil {
+			return -1
+		}
+		index := len(jsonWalls)
+		jsonWalls = append(jsonWalls, data.Wall{})
+		jsonWall := data.Wall{
+			LeftEdgeX:  float32(wall.LeftX),
+			LeftEdgeZ:  -float32(wall.LeftZ),
+			RightEdgeX: float32(wall.RightX),
+			RightEdgeZ: -float32(wall.RightZ),
+			OffsetU:    float32(wall.OffsetU),
+			FrontWall:  processWall(wall.Front),
+			BackWall:   processWall(wall.Back),
+		}
+		if wall.Floor != nil {
+			extrusion := &data.Extrusion{
+				Top:    -float32(wall.Floor.Top),
+				Bottom: -float32(wall.Floor.Bottom),
+			}
+			extrusion.OuterTexture, extrusion.OuterLight = registerMaterial(wall.Floor.OuterTextureName)
+			extrusion.FaceTexture, extrusion.FaceLight = registerMaterial(wall.Floor.FaceTextureName)
+			extrusion.InnerTexture, extrusion.InnerLight = registerMaterial(wall.Floor.InnerTextureName)
+			extrusion.OuterMapping = flatTextureMapping(wall.Floor.OuterMapping, textureSize)
+			extrusion.FaceMapping = wallTextureMapping(wall.Floor.FaceMapping, wall, textureSize)
+			extrusion.InnerMapping = flatTextureMapping(wall.Floor.InnerMapping, textureSize)
+			extrusion.Sky = isSkyMaterial(wall.Floor.OuterTextureName)
+			extrusion.Mirror = isMirrorMaterial(wall.Floor.FaceTextureName)
+			jsonWall.Floor = extrusion
+		}
+		if wall.Ceiling != nil {
+			extrusion := &data.Extrusion{
+				Top:    -float32(wall.Ceiling.Top),
+				Bottom: -float32(wall.Ceiling.Bottom),
+			}
+			extrusion.InnerTexture, extrusion.InnerLight = registerMaterial(wall.Ceiling.InnerTextureName)
+			extrusion.FaceTexture, extrusion.FaceLight = registerMaterial(wall.Ceiling.FaceTextureName)
+			extrusion.OuterTexture, extrusion.OuterLight = registerMaterial(wall.Ceiling.OuterTextureName)
+			extrusion.InnerMapping = flatTextureMapping(wall.Ceiling.InnerMapping, textureSize)
+			extrusion.FaceMapping = wallTextureMapping(wall.Ceiling.FaceMapping, wall, textureSize)
+			extrusion.OuterMapping = flatTextureMapping(wall.Ceiling.OuterMapping, textureSize)
+			extrusion.Sky = isSkyMaterial(wall.Ceiling.OuterTextureName)
+			extrusion.Mirror = isMirrorMaterial(wall.Ceiling.FaceTextureName)
+			jsonWall.Ceiling = extrusion
+		}
+		jsonWalls[index] = jsonWall
+		return index
+	}
+	processWall(root)
+
+	return data.Level{
+		Textures: jsonTextures,
+		Walls:    jsonWalls,
+	}
+}
+
+// wallTextureMapping converts the UV mapping of the face of the specified
+// wall into a texture mapping, where U is measured from the origin of the
+// wall, as specified by its U offset. Textures that are rotated on the face
+// are not supported.
+func wallTextureMapping(mapping *scene.UVMapping, wall *bsp.Wall, textureSize float64) *data.TextureMapping {
+	if mapping == nil {
+		return nil
+	}
+	length := dprec.Vec3Diff(wall.FlatRight(), wall.FlatLeft()).Length()
+	uvLeft := mapping.UV(wall.FlatLeft())
+	uvRight := mapping.UV(wall.FlatRight())
+	// The Y axis of the level points downward, unlike the one of the model.
+	uvBelowLeft := mapping.UV(dprec.NewVec3(wall.LeftX, -1.0, wall.LeftZ))
+	scaleU := textureSize * (uvRight.X - uvLeft.X) / length
+	return &data.TextureMapping{
+		OffsetU: float32(wrapTexelOffset(textureSize*uvLeft.X-scaleU*wall.OffsetU, textureSize)),
+		OffsetV: float32(wrapTexelOffset(textureSize*(1.0-uvLeft.Y), textureSize)),
+		ScaleU:  float32(scaleU),
+		ScaleV:  float32(textureSize * (uvLeft.Y - uvBelowLeft.Y)),
+	}
+}
+
+// flatTextureMapping converts the UV mapping of a floor or a ceiling into
+// a texture mapping. Textures that are rotated on the surface are not
+// supported.
+func flatTextureMapping(mapping *scene.UVMapping, textureSize float64) *data.TextureMapping {
+	if mapping == nil {
+		return nil
+	}
+	// The Z axis of the level points in the opposite direction to
+	// the one of the model.
+	height := mapping.Origin.Y
+	uvOrigin := mapping.UV(dprec.NewVec3(0.0, height, 0.0))
+	uvX := mapping.UV(dprec.NewVec3(1.0, height, 0.0))
+	uvZ := mapping.UV(dprec.NewVec3(0.0, height, -1.0))
+	return &data.TextureMapping{
+		OffsetU: float32(wrapTexelOffset(textureSize*uvOrigin.X, textureSize)),
+		OffsetV: float32(wrapTexelOffset(textureSize*(1.0-uvOrigin.Y), textureSize)),
+		ScaleU:  float32(textureSize * (uvX.X - uvOrigin.X)),
+		ScaleV:  float32(textureSize * (uvOrigin.Y - uvZ.Y)),
+	}
+}
+
+// wrapTexelOffset brings the specified texel offset into the range
+// [0, textureSize), since textures repeat.
+func wrapTexelOffset(offset, textureSize float64) float64 {
+	offset = math.Mod(offset, textureSize)
+	if offset < 0.0 {
+		offset += textureSize
+	}
+	return offset
+}
+
+// parseMaterialName splits a material name of the form `<texture>@<light>`
+// into the texture name and the light level. The returned light level is
+// nil if the material name does not specify a valid one.
+func parseMaterialName(name string, logger *log.Logger) (string, *float32) {
+	textureName, lightText, ok := strings.Cut(name, "@")
+	if !ok {
+		return name, nil
+	}
+	light, err := strconv.ParseFloat(lightText, 32)
+	if err != nil || light < 0.0 || light > 1.0 {
+		logger.Printf("warning: ignoring invalid light %q of material %q\n", lightText, name)
+		return textureName, nil
+	}
+	result := float32(light)
+	return textureName, &result
+}
@@ -0,0 +1,133 @@
+package bsp
+
+import "math"
+
+const collisionIterations = 4
+
+// Body represents an upright cylinder that can collide with walls.
+// As with the rest of the scene, the Y axis points downward, hence Top
+// is expected to be smaller than Bottom.
+type Body struct {
+	X      float32
+	Z      float32
+	Radius float32
+	Top    float32
+	Bottom float32
+}
+
+// Collide moves the specified body out of all walls of the tree with the
+// specified root that obstruct it. Walls push the body along their normal,
+// which allows it to slide along them instead of stopping.
+// The adjusted body is returned.
+func Collide(root *Wall, body Body) Body {
+	for i := 0; i < collisionIterations; i++ {
+		if !collideTree(root, &body) {
+			break
+		}
+	}
+	return body
+}
+
+// collideTree resolves collisions between the body and the walls of the
+// tree with the specified root. Subtrees that are further than the body
+// radius from a partitioning wall are skipped.
+// It returns whether the body had to be moved.
+func collideTree(wall *Wall, body *Body) bool {
+	if wall == nil {
+		return false
+	}
+
+	moved := false
+	distance := wall.Distance(body.X, body.Z)
+	if distance > -body.Radius {
+		moved = collideTree(wall.FrontWall, body) || moved
+	}
+	if wall.Obstructs(body.Top, body.Bottom) {
+		moved = collideWall(wall, body) || moved
+	}
+	if distance < body.Radius {
+		moved = collideTree(wall.BackWall, body) || moved
+	}
+	return moved
+}
+
+// collideWall pushes the body away from the closest point on the wall,
+// in case the body intersects it.
+// It returns whether the body had to be moved.
+func collideWall(wall *Wall, body *Body) bool {
+	closestX, closestZ := wall.ClosestPoint(body.X, body.Z)
+	deltaX := body.X - closestX
+	deltaZ := body.Z - closestZ
+	distanceSqr := deltaX*deltaX + deltaZ*deltaZ
+	if distanceSqr >= body.Radius*body.Radius {
+		return false
+	}
+
+	distance := float32(math.Sqrt(float64(distanceSqr)))
+	if distance < 0.001 {
+		// The body center lies on the wall, so the direction of the
+		// push is determined by the wall normal.
+		normalX, normalZ := wall.Normal()
+		body.X = closestX + normalX*body.Radius
+		body.Z = closestZ + normalZ*body.Radius
+		return true
+	}
+	body.X = closestX + deltaX*(body.Radius/distance)
+	body.Z = closestZ + deltaZ*(body.Radius/distance)
+	return true
+}
+
+// FloorHeight returns the height of the floor at the specified position.
+// The floor is determined by the BSP region that contains the position,
+// which is found by descending the tree. The last wall on the way that
+// has a floor extrusion bounds the region, so the side of that wall on
+// which the position lies specifies the height. The second return value
+// is false if no wall on the way has a floor extrusion.
+func FloorHeight(root *Wall, x, z float32) (float32, bool) {
+	wall, front := regionWall(root, x, z, (*Wall).HasFloorExtrusion)
+	if wall == nil {
+		return 0.0, false
+	}
+	if front {
+		return wall.Floor.Bottom, true
+	}
+	return wall.Floor.Top, true
+}
+
+// CeilingHeight returns the height of the ceiling at the specified
+// position. It follows the same logic as FloorHeight but uses walls with
+// ceiling extrusions.
+func CeilingHeight(root *Wall, x, z float32) (float32, bool) {
+	wall, front := regionWall(root, x, z, (*Wall).HasCeilingExtrusion)
+	if wall == nil {
+		return 0.0, false
+	}
+	if front {
+		return wall.Ceiling.Top, true
+	}
+	return wall.Ceiling.Bottom, true
+}
+
+// regionWall descends the tree with the specified root to the region that
+// contains the specified point, in the same way as the renderer does for
+// the camera. It returns the last wall on the way that matches the filter,
+// along with whether the point is in front of it.
+func regionWall(root *Wall, x, z float32, filter func(*Wall) bool) (*Wall, bool) {
+	var (
+		bestWall  *Wall
+		bestFront bool
+	)
+	for wall := root; wall != nil; {
+		front := wall.isFrontFacing(x, z)
+		if filter(wall) {
+			bestWall = wall
+			bestFront = front
+		}
+		if front {
+			wall = wall.FrontWall
+		} else {
+			wall = wall.BackWall
+		}
+	}
+	return bestWall, bestFront
+}
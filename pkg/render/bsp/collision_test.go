@@ -0,0 +1,111 @@
+package bsp
+
+import (
+	"math"
+	"testing"
+)
+
+func TestCollide(t *testing.T) {
+	// A wall along the X axis with its front side facing toward negative Z.
+	wall := &Wall{
+		LeftEdgeX:  -100.0,
+		LeftEdgeZ:  0.0,
+		RightEdgeX: 100.0,
+		RightEdgeZ: 0.0,
+		Length:     200.0,
+		Floor: &Extrusion{
+			Top:    -50.0,
+			Bottom: 0.0,
+		},
+	}
+
+	t.Run("pushes body out along normal", func(t *testing.T) {
+		body := Collide(wall, Body{X: 10.0, Z: -5.0, Radius: 16.0, Top: -100.0, Bottom: -10.0})
+		assertPosition(t, body, 10.0, -16.0)
+	})
+
+	t.Run("keeps tangential movement", func(t *testing.T) {
+		body := Collide(wall, Body{X: 40.0, Z: -10.0, Radius: 16.0, Top: -100.0, Bottom: -10.0})
+		assertPosition(t, body, 40.0, -16.0)
+	})
+
+	t.Run("pushes body away from edge", func(t *testing.T) {
+		body := Collide(wall, Body{X: 110.0, Z: 0.0, Radius: 16.0, Top: -100.0, Bottom: -10.0})
+		assertPosition(t, body, 116.0, 0.0)
+	})
+
+	t.Run("ignores distant body", func(t *testing.T) {
+		body := Collide(wall, Body{X: 10.0, Z: -20.0, Radius: 16.0, Top: -100.0, Bottom: -10.0})
+		assertPosition(t, body, 10.0, -20.0)
+	})
+
+	t.Run("ignores body above extrusion", func(t *testing.T) {
+		body := Collide(wall, Body{X: 10.0, Z: -5.0, Radius: 16.0, Top: -100.0, Bottom: -60.0})
+		assertPosition(t, body, 10.0, -5.0)
+	})
+}
+
+func assertPosition(t *testing.T, body Body, x, z float32) {
+	t.Helper()
+	if math.Abs(float64(body.X-x)) > 0.01 || math.Abs(float64(body.Z-z)) > 0.01 {
+		t.Fatalf("expected position (%f, %f), got (%f, %f)", x, z, body.X, body.Z)
+	}
+}
+
+func TestFloorHeight(t *testing.T) {
+	// Two steps along the X axis, each raised on its back side (positive Z).
+	step := &Wall{
+		LeftEdgeX:  -100.0,
+		LeftEdgeZ:  0.0,
+		RightEdgeX: 100.0,
+		RightEdgeZ: 0.0,
+		Length:     200.0,
+		Floor: &Extrusion{
+			Top:    -20.0,
+			Bottom: 0.0,
+		},
+	}
+	step.BackWall = &Wall{
+		LeftEdgeX:  -100.0,
+		LeftEdgeZ:  500.0,
+		RightEdgeX: 100.0,
+		RightEdgeZ: 500.0,
+		Length:     200.0,
+		Floor: &Extrusion{
+			Top:    -60.0,
+			Bottom: -20.0,
+		},
+	}
+	// A block in front of the steps, raised on its back side (positive X),
+	// which is closer to some points behind the first step than the steps
+	// themselves.
+	step.FrontWall = &Wall{
+		LeftEdgeX:  160.0,
+		LeftEdgeZ:  -1.0,
+		RightEdgeX: 160.0,
+		RightEdgeZ: -100.0,
+		Length:     99.0,
+		Floor: &Extrusion{
+			Top:    -40.0,
+			Bottom: 0.0,
+		},
+	}
+
+	assertFloorHeight := func(x, z, expected float32) {
+		t.Helper()
+		height, ok := FloorHeight(step, x, z)
+		if !ok {
+			t.Fatalf("expected floor at (%f, %f)", x, z)
+		}
+		if height != expected {
+			t.Fatalf("expected floor height %f at (%f, %f), got %f", expected, x, z, height)
+		}
+	}
+	assertFloorHeight(0.0, -50.0, 0.0)
+	assertFloorHeight(0.0, 50.0, -20.0)
+	assertFloorHeight(0.0, 450.0, -20.0)
+	assertFloorHeight(0.0, 550.0, -60.0)
+	assertFloorHeight(150.0, -50.0, 0.0)
+	assertFloorHeight(170.0, -50.0, -40.0)
+	assertFloorHeight(150.0, 5.0, -20.0)
+}
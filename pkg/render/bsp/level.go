@@ -0,0 +1,149 @@
+package bsp
+
+import (
+	"math"
+
+	"github.com/mokiat/softgfx/pkg/data"
+	"github.com/mokiat/softgfx/pkg/render/graphics"
+	"github.com/mokiat/softgfx/pkg/render/scene"
+)
+
+// BuildTree converts the walls of the specified level into a BSP tree
+// and returns the root wall. The textures slice is indexed by the
+// texture indices that are referenced by the level walls.
+// If the level has no walls, nil is returned.
+func BuildTree(level data.Level, textures []*graphics.Texture) *Wall {
+	if len(level.Walls) == 0 {
+		return nil
+	}
+
+	getTexture := func(index int) *graphics.Texture {
+		if index < 0 || index >= len(textures) {
+			return nil
+		}
+		return textures[index]
+	}
+
+	getLight := func(light *float32) float32 {
+		if light == nil {
+			return data.DefaultLight
+		}
+		return *light
+	}
+
+	getMapping := func(mapping *data.TextureMapping) scene.TextureMapping {
+		if mapping == nil {
+			return scene.DefaultTextureMapping
+		}
+		return scene.TextureMapping{
+			OffsetU: mapping.OffsetU,
+			OffsetV: mapping.OffsetV,
+			ScaleU:  mapping.ScaleU,
+			ScaleV:  mapping.ScaleV,
+		}
+	}
+
+	// Extrusions that override the tint or fog of the level share their
+	// shading tables, since these are expensive to create.
+	type shading struct {
+		tint data.Color
+		fog  data.Color
+	}
+	shadingTables := make(map[shading]*graphics.ShadingTable)
+	getShadingTable := func(extrusion *data.Extrusion) *graphics.ShadingTable {
+		if extrusion.Tint == nil && extrusion.Fog == nil {
+			return nil
+		}
+		key := shading{
+			tint: overrideColor(extrusion.Tint, level.Tint, data.Color{R: 255, G: 255, B: 255}),
+			fog:  overrideColor(extrusion.Fog, level.Fog, data.Color{R: 0, G: 0, B: 0}),
+		}
+		table, ok := shadingTables[key]
+		if !ok {
+			table = graphics.NewShadingTable(convertColor(key.tint), convertColor(key.fog))
+			shadingTables[key] = table
+		}
+		return table
+	}
+
+	walls := make([]*Wall, len(level.Walls))
+	for i, levelWall := range level.Walls {
+		deltaX := float64(levelWall.RightEdgeX - levelWall.LeftEdgeX)
+		deltaZ := float64(levelWall.RightEdgeZ - levelWall.LeftEdgeZ)
+		wall := &Wall{
+			LeftEdgeX:  levelWall.LeftEdgeX,
+			LeftEdgeZ:  levelWall.LeftEdgeZ,
+			RightEdgeX: levelWall.RightEdgeX,
+			RightEdgeZ: levelWall.RightEdgeZ,
+			Length:     float32(math.Sqrt(deltaX*deltaX + deltaZ*deltaZ)),
+			OffsetU:    levelWall.OffsetU,
+		}
+		if levelWall.Ceiling != nil {
+			wall.Ceiling = &Extrusion{
+				Top:          levelWall.Ceiling.Top,
+				Bottom:       levelWall.Ceiling.Bottom,
+				OuterTexture: getTexture(levelWall.Ceiling.OuterTexture),
+				FaceTexture:  getTexture(levelWall.Ceiling.FaceTexture),
+				InnerTexture: getTexture(levelWall.Ceiling.InnerTexture),
+				OuterLight:   getLight(levelWall.Ceiling.OuterLight),
+				FaceLight:    getLight(levelWall.Ceiling.FaceLight),
+				InnerLight:   getLight(levelWall.Ceiling.InnerLight),
+				OuterMapping: getMapping(levelWall.Ceiling.OuterMapping),
+				FaceMapping:  getMapping(levelWall.Ceiling.FaceMapping),
+				InnerMapping: getMapping(levelWall.Ceiling.InnerMapping),
+				Sky:          levelWall.Ceiling.Sky,
+				Mirror:       levelWall.Ceiling.Mirror,
+				ShadingTable: getShadingTable(levelWall.Ceiling),
+			}
+		}
+		if levelWall.Floor != nil {
+			wall.Floor = &Extrusion{
+				Top:          levelWall.Floor.Top,
+				Bottom:       levelWall.Floor.Bottom,
+				OuterTexture: getTexture(levelWall.Floor.OuterTexture),
+				FaceTexture:  getTexture(levelWall.Floor.FaceTexture),
+				InnerTexture: getTexture(levelWall.Floor.InnerTexture),
+				OuterLight:   getLight(levelWall.Floor.OuterLight),
+				FaceLight:    getLight(levelWall.Floor.FaceLight),
+				InnerLight:   getLight(levelWall.Floor.InnerLight),
+				OuterMapping: getMapping(levelWall.Floor.OuterMapping),
+				FaceMapping:  getMapping(levelWall.Floor.FaceMapping),
+				InnerMapping: getMapping(levelWall.Floor.InnerMapping),
+				Sky:          levelWall.Floor.Sky,
+				Mirror:       levelWall.Floor.Mirror,
+				ShadingTable: getShadingTable(levelWall.Floor),
+			}
+		}
+		if levelWall.Middle != nil {
+			wall.MiddleTexture = getTexture(levelWall.Middle.Texture)
+			wall.MiddleLight = getLight(levelWall.Middle.Light)
+			wall.MiddleMapping = getMapping(levelWall.Middle.Mapping)
+		}
+		walls[i] = wall
+	}
+	for i, levelWall := range level.Walls {
+		if frontIndex := levelWall.FrontWall; frontIndex >= 0 {
+			walls[i].FrontWall = walls[frontIndex]
+		}
+		if backIndex := levelWall.BackWall; backIndex >= 0 {
+			walls[i].BackWall = walls[backIndex]
+		}
+	}
+	return walls[0]
+}
+
+// overrideColor returns the override color if it is set, otherwise the
+// level color if it is set, otherwise the default color.
+func overrideColor(override, level *data.Color, defaultColor data.Color) data.Color {
+	if override != nil {
+		return *override
+	}
+	if level != nil {
+		return *level
+	}
+	return defaultColor
+}
+
+func convertColor(color data.Color) graphics.Color {
+	return graphics.Color{R: color.R, G: color.G, B: color.B}
+}
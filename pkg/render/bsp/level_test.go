@@ -0,0 +1,86 @@
+package bsp
+
+import (
+	"testing"
+
+	"github.com/mokiat/softgfx/pkg/data"
+	"github.com/mokiat/softgfx/pkg/render/graphics"
+)
+
+func TestBuildTreeShadingTables(t *testing.T) {
+	red := &data.Color{R: 255, G: 0, B: 0}
+	blue := &data.Color{R: 0, G: 0, B: 255}
+	gray := &data.Color{R: 128, G: 128, B: 128}
+
+	level := data.Level{
+		Textures: []string{"wall"},
+		Fog:      gray,
+		Walls: []data.Wall{
+			{
+				RightEdgeX: 10.0,
+				Ceiling:    &data.Extrusion{Top: -100.0, Bottom: -50.0},
+				Floor:      &data.Extrusion{Top: 0.0, Bottom: 20.0, Tint: red},
+				FrontWall:  1,
+				BackWall:   -1,
+			},
+			{
+				RightEdgeX: 10.0,
+				Ceiling:    &data.Extrusion{Top: -100.0, Bottom: -50.0, Tint: red},
+				Floor:      &data.Extrusion{Top: 0.0, Bottom: 20.0, Tint: red, Fog: blue},
+				FrontWall:  -1,
+				BackWall:   -1,
+			},
+		},
+	}
+	texture := newWhiteTexture(t)
+	root := BuildTree(level, []*graphics.Texture{texture})
+	front := root.FrontWall
+
+	if root.Ceiling.ShadingTable != nil {
+		t.Errorf("expected extrusion without overrides to have no shading table")
+	}
+	if root.Floor.ShadingTable == nil {
+		t.Fatalf("expected extrusion with overrides to have a shading table")
+	}
+	if front.Ceiling.ShadingTable != root.Floor.ShadingTable {
+		t.Errorf("expected extrusions with the same overrides to share a shading table")
+	}
+
+	// The tint is overridden, while the fog falls back to that of the level.
+	assertShade(t, texture, root.Floor.ShadingTable, 0, graphics.Color{R: 255, G: 0, B: 0})
+	assertShade(t, texture, root.Floor.ShadingTable, 255, graphics.Color{R: 128, G: 128, B: 128})
+
+	// Both the tint and the fog are overridden.
+	assertShade(t, texture, front.Floor.ShadingTable, 0, graphics.Color{R: 255, G: 0, B: 0})
+	assertShade(t, texture, front.Floor.ShadingTable, 255, graphics.Color{R: 0, G: 0, B: 255})
+}
+
+func newWhiteTexture(t *testing.T) *graphics.Texture {
+	t.Helper()
+	texels := make([]byte, 4*4*4)
+	for i := range texels {
+		texels[i] = 255
+	}
+	texture, err := graphics.NewTexture(4, 4, texels)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return texture
+}
+
+// assertShade plots the specified texture with the specified shading
+// table and shade amount and checks the color of the resulting pixel.
+func assertShade(t *testing.T, texture *graphics.Texture, table *graphics.ShadingTable, amount int, expected graphics.Color) {
+	t.Helper()
+	plotter := graphics.NewImagePlotter(1, 1)
+	plotter.PlotHorizontalStripe(graphics.HorizontalStripe{
+		Texture:        texture,
+		TexShadeAmount: amount,
+		ShadingTable:   table,
+	})
+	plotter.Flush()
+	pixel := plotter.Image().RGBAAt(0, 0)
+	if actual := (graphics.Color{R: pixel.R, G: pixel.G, B: pixel.B}); actual != expected {
+		t.Errorf("expected color %+v at shade amount %d, got %+v", expected, amount, actual)
+	}
+}
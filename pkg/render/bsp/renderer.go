@@ -0,0 +1,238 @@
+package bsp
+
+import "github.com/mokiat/softgfx/pkg/render/scene"
+
+func NewRenderer(sceneRenderer *scene.Renderer) *Renderer {
+	return &Renderer{
+		sceneRenderer: sceneRenderer,
+	}
+}
+
+type Renderer struct {
+	sceneRenderer *scene.Renderer
+}
+
+func (r *Renderer) Clear() {
+	r.sceneRenderer.Clear()
+}
+
+func (r *Renderer) RenderBSP(wall *Wall, camera *scene.Camera) {
+	if wall == nil {
+		return
+	}
+
+	if r.sceneRenderer.Saturated() {
+		return
+	}
+
+	if wall.IsFrontFacing(camera) {
+		r.RenderBSP(wall.FrontWall, camera)
+		r.renderWallFront(wall, camera)
+		r.RenderBSP(wall.BackWall, camera)
+	} else {
+		r.RenderBSP(wall.BackWall, camera)
+		r.renderWallBack(wall, camera)
+		r.RenderBSP(wall.FrontWall, camera)
+	}
+}
+
+func (r *Renderer) renderWallFront(wall *Wall, camera *scene.Camera) {
+	if wall.IsContinuous() {
+		r.sceneRenderer.RenderSegment(scene.Segment{
+			LeftX:               wall.LeftEdgeX,
+			LeftZ:               wall.LeftEdgeZ,
+			RightX:              wall.RightEdgeX,
+			RightZ:              wall.RightEdgeZ,
+			Length:              wall.Length,
+			OffsetU:             wall.OffsetU,
+			Top:                 wall.Ceiling.Top,
+			Bottom:              wall.Floor.Bottom,
+			CeilingTexture:      wall.Ceiling.OuterTexture,
+			CeilingLight:        wall.Ceiling.OuterLight,
+			CeilingShadingTable: wall.Ceiling.ShadingTable,
+			CeilingMapping:      wall.Ceiling.OuterMapping,
+			CeilingSky:          wall.Ceiling.Sky,
+			FaceTexture:         wall.Ceiling.FaceTexture,
+			FaceLight:           wall.Ceiling.FaceLight,
+			FaceShadingTable:    wall.Ceiling.ShadingTable,
+			FaceMapping:         wall.Ceiling.FaceMapping,
+			FaceMirror:          wall.Ceiling.Mirror,
+			FloorTexture:        wall.Floor.OuterTexture,
+			FloorLight:          wall.Floor.OuterLight,
+			FloorShadingTable:   wall.Floor.ShadingTable,
+			FloorMapping:        wall.Floor.OuterMapping,
+			FloorSky:            wall.Floor.Sky,
+		}, camera)
+		return
+	}
+
+	if wall.HasCeilingExtrusion() {
+		r.sceneRenderer.RenderSegment(scene.Segment{
+			LeftX:               wall.LeftEdgeX,
+			LeftZ:               wall.LeftEdgeZ,
+			RightX:              wall.RightEdgeX,
+			RightZ:              wall.RightEdgeZ,
+			Length:              wall.Length,
+			OffsetU:             wall.OffsetU,
+			Top:                 wall.Ceiling.Top,
+			Bottom:              wall.Ceiling.Bottom,
+			CeilingTexture:      wall.Ceiling.OuterTexture,
+			CeilingLight:        wall.Ceiling.OuterLight,
+			CeilingShadingTable: wall.Ceiling.ShadingTable,
+			CeilingMapping:      wall.Ceiling.OuterMapping,
+			CeilingSky:          wall.Ceiling.Sky,
+			FaceTexture:         wall.Ceiling.FaceTexture,
+			FaceLight:           wall.Ceiling.FaceLight,
+			FaceShadingTable:    wall.Ceiling.ShadingTable,
+			FaceMapping:         wall.Ceiling.FaceMapping,
+			FaceMirror:          wall.Ceiling.Mirror,
+		}, camera)
+	}
+
+	if wall.HasFloorExtrusion() {
+		r.sceneRenderer.RenderSegment(scene.Segment{
+			LeftX:             wall.LeftEdgeX,
+			LeftZ:             wall.LeftEdgeZ,
+			RightX:            wall.RightEdgeX,
+			RightZ:            wall.RightEdgeZ,
+			Length:            wall.Length,
+			OffsetU:           wall.OffsetU,
+			Top:               wall.Floor.Top,
+			Bottom:            wall.Floor.Bottom,
+			FaceTexture:       wall.Floor.FaceTexture,
+			FaceLight:         wall.Floor.FaceLight,
+			FaceShadingTable:  wall.Floor.ShadingTable,
+			FaceMapping:       wall.Floor.FaceMapping,
+			FaceMirror:        wall.Floor.Mirror,
+			FloorTexture:      wall.Floor.OuterTexture,
+			FloorLight:        wall.Floor.OuterLight,
+			FloorShadingTable: wall.Floor.ShadingTable,
+			FloorMapping:      wall.Floor.OuterMapping,
+			FloorSky:          wall.Floor.Sky,
+		}, camera)
+	}
+
+	r.renderWallMiddleFront(wall, camera)
+}
+
+func (r *Renderer) renderWallBack(wall *Wall, camera *scene.Camera) {
+	if !wall.IsSplit() {
+		return
+	}
+
+	if wall.HasCeilingExtrusion() && wall.HasFloorExtrusion() {
+		r.sceneRenderer.RenderSegment(scene.Segment{
+			LeftX:               wall.RightEdgeX,
+			LeftZ:               wall.RightEdgeZ,
+			RightX:              wall.LeftEdgeX,
+			RightZ:              wall.LeftEdgeZ,
+			Length:              wall.Length,
+			Top:                 wall.Ceiling.Bottom,
+			Bottom:              wall.Floor.Top,
+			CeilingTexture:      wall.Ceiling.InnerTexture,
+			CeilingLight:        wall.Ceiling.InnerLight,
+			CeilingShadingTable: wall.Ceiling.ShadingTable,
+			CeilingMapping:      wall.Ceiling.InnerMapping,
+			FloorTexture:        wall.Floor.InnerTexture,
+			FloorLight:          wall.Floor.InnerLight,
+			FloorShadingTable:   wall.Floor.ShadingTable,
+			FloorMapping:        wall.Floor.InnerMapping,
+		}, camera)
+		r.renderWallMiddleBack(wall, camera)
+		return
+	}
+
+	if wall.HasCeilingExtrusion() {
+		r.sceneRenderer.RenderSegment(scene.Segment{
+			LeftX:               wall.RightEdgeX,
+			LeftZ:               wall.RightEdgeZ,
+			RightX:              wall.LeftEdgeX,
+			RightZ:              wall.LeftEdgeZ,
+			Length:              wall.Length,
+			Top:                 wall.Ceiling.Bottom,
+			Bottom:              wall.Ceiling.Bottom,
+			CeilingTexture:      wall.Ceiling.InnerTexture,
+			CeilingLight:        wall.Ceiling.InnerLight,
+			CeilingShadingTable: wall.Ceiling.ShadingTable,
+			CeilingMapping:      wall.Ceiling.InnerMapping,
+		}, camera)
+	}
+
+	if wall.HasFloorExtrusion() {
+		r.sceneRenderer.RenderSegment(scene.Segment{
+			LeftX:             wall.RightEdgeX,
+			LeftZ:             wall.RightEdgeZ,
+			RightX:            wall.LeftEdgeX,
+			RightZ:            wall.LeftEdgeZ,
+			Length:            wall.Length,
+			Top:               wall.Floor.Top,
+			Bottom:            wall.Floor.Top,
+			FloorTexture:      wall.Floor.InnerTexture,
+			FloorLight:        wall.Floor.InnerLight,
+			FloorShadingTable: wall.Floor.ShadingTable,
+			FloorMapping:      wall.Floor.InnerMapping,
+		}, camera)
+	}
+
+	r.renderWallMiddleBack(wall, camera)
+}
+
+func (r *Renderer) renderWallMiddleFront(wall *Wall, camera *scene.Camera) {
+	if !wall.HasMiddle() {
+		return
+	}
+	r.sceneRenderer.RenderTranslucentSegment(middleFrontSegment(wall), camera)
+}
+
+func (r *Renderer) renderWallMiddleBack(wall *Wall, camera *scene.Camera) {
+	if !wall.HasMiddle() {
+		return
+	}
+	r.sceneRenderer.RenderTranslucentSegment(middleBackSegment(wall), camera)
+}
+
+// middleFrontSegment returns the segment of the middle texture of the
+// specified wall, as seen from the front of the wall.
+func middleFrontSegment(wall *Wall) scene.Segment {
+	top, bottom := middleRange(wall)
+	return scene.Segment{
+		LeftX:       wall.LeftEdgeX,
+		LeftZ:       wall.LeftEdgeZ,
+		RightX:      wall.RightEdgeX,
+		RightZ:      wall.RightEdgeZ,
+		Length:      wall.Length,
+		OffsetU:     wall.OffsetU,
+		Top:         top,
+		Bottom:      bottom,
+		FaceTexture: wall.MiddleTexture,
+		FaceLight:   wall.MiddleLight,
+		FaceMapping: wall.MiddleMapping,
+	}
+}
+
+// middleBackSegment returns the segment of the middle texture of the
+// specified wall, as seen from the back of the wall. The edges are swapped
+// and the U mapping is reversed, so that each point of the wall has the
+// same texel from both sides.
+func middleBackSegment(wall *Wall) scene.Segment {
+	segment := middleFrontSegment(wall)
+	segment.LeftX, segment.LeftZ = wall.RightEdgeX, wall.RightEdgeZ
+	segment.RightX, segment.RightZ = wall.LeftEdgeX, wall.LeftEdgeZ
+	segment.OffsetU = -(wall.OffsetU + wall.Length)
+	segment.FaceMapping.ScaleU = -segment.FaceMapping.ScaleU
+	return segment
+}
+
+// middleRange returns the vertical range of the middle texture of
+// the specified split wall. Where an extrusion is missing, the range
+// extends from the other extrusion by the height of the texture.
+func middleRange(wall *Wall) (float32, float32) {
+	switch {
+	case !wall.HasCeilingExtrusion():
+		return wall.Floor.Top - float32(wall.MiddleTexture.Height), wall.Floor.Top
+	case !wall.HasFloorExtrusion():
+		return wall.Ceiling.Bottom, wall.Ceiling.Bottom + float32(wall.MiddleTexture.Height)
+	default:
+		return wall.Ceiling.Bottom, wall.Floor.Top
+	}
+}
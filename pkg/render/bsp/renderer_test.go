@@ -0,0 +1,58 @@
+package bsp
+
+import (
+	"math"
+	"testing"
+
+	"github.com/mokiat/softgfx/pkg/render/scene"
+)
+
+func TestMiddleSegmentMapping(t *testing.T) {
+	// A diagonal wall of length 100 with a middle texture that is offset
+	// and scaled along U.
+	wall := &Wall{
+		LeftEdgeX:  10.0,
+		LeftEdgeZ:  20.0,
+		RightEdgeX: 70.0,
+		RightEdgeZ: 100.0,
+		Length:     100.0,
+		OffsetU:    30.0,
+		Ceiling: &Extrusion{
+			Top:    -100.0,
+			Bottom: -50.0,
+		},
+		Floor: &Extrusion{
+			Top:    0.0,
+			Bottom: 20.0,
+		},
+		MiddleMapping: scene.TextureMapping{
+			OffsetU: 5.0,
+			OffsetV: 0.0,
+			ScaleU:  2.0,
+			ScaleV:  1.0,
+		},
+	}
+	front := middleFrontSegment(wall)
+	back := middleBackSegment(wall)
+
+	// segmentU returns the U texture coordinate of the world point at
+	// the specified fraction of the wall length, from its left edge.
+	segmentU := func(segment scene.Segment, fraction float32) float32 {
+		x := wall.LeftEdgeX + (wall.RightEdgeX-wall.LeftEdgeX)*fraction
+		z := wall.LeftEdgeZ + (wall.RightEdgeZ-wall.LeftEdgeZ)*fraction
+		deltaX := x - segment.LeftX
+		deltaZ := z - segment.LeftZ
+		distance := float32(math.Sqrt(float64(deltaX*deltaX + deltaZ*deltaZ)))
+		return segment.FaceMapping.U(segment.OffsetU + distance)
+	}
+
+	for _, fraction := range []float32{0.0, 0.25, 0.6, 1.0} {
+		expected := 2.0*(30.0+100.0*fraction) + 5.0
+		if frontU := segmentU(front, fraction); math.Abs(float64(frontU-expected)) > 0.001 {
+			t.Errorf("expected front U %f at %f of the wall, got %f", expected, fraction, frontU)
+		}
+		if backU := segmentU(back, fraction); math.Abs(float64(backU-expected)) > 0.001 {
+			t.Errorf("expected back U %f at %f of the wall, got %f", expected, fraction, backU)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+// Package bsp renders levels that are organized as binary space
+// partitioning trees of extruded walls.
+package bsp
+
+import (
+	"math"
+
+	"github.com/mokiat/softgfx/pkg/render/graphics"
+	"github.com/mokiat/softgfx/pkg/render/scene"
+)
+
+type Wall struct {
+	LeftEdgeX  float32
+	LeftEdgeZ  float32
+	RightEdgeX float32
+	RightEdgeZ float32
+	Length     float32
+
+	// OffsetU is the distance along the line of the wall from the origin
+	// of the U texture coordinate to the left edge of the wall.
+	OffsetU float32
+
+	Ceiling *Extrusion
+	Floor   *Extrusion
+
+	// MiddleTexture, if set, is drawn in the gap of a split wall.
+	MiddleTexture *graphics.Texture
+	MiddleLight   float32
+	MiddleMapping scene.TextureMapping
+
+	FrontWall *Wall
+	BackWall  *Wall
+}
+
+type Extrusion struct {
+	Top          float32
+	Bottom       float32
+	OuterTexture *graphics.Texture
+	FaceTexture  *graphics.Texture
+	InnerTexture *graphics.Texture
+	OuterLight   float32
+	FaceLight    float32
+	InnerLight   float32
+	OuterMapping scene.TextureMapping
+	FaceMapping  scene.TextureMapping
+	InnerMapping scene.TextureMapping
+
+	// Sky specifies that the outer side of the extrusion shows the sky.
+	Sky bool
+
+	// Mirror specifies that the face of the extrusion reflects the level.
+	Mirror bool
+
+	// ShadingTable, if set, is used to shade all sides of the extrusion
+	// instead of the shading table of the renderer.
+	ShadingTable *graphics.ShadingTable
+}
+
+func (w *Wall) HasCeilingExtrusion() bool {
+	return w.Ceiling != nil
+}
+
+func (w *Wall) HasFloorExtrusion() bool {
+	return w.Floor != nil
+}
+
+func (w *Wall) HasMiddle() bool {
+	return w.IsSplit() && (w.MiddleTexture != nil)
+}
+
+func (w *Wall) IsSplit() bool {
+	if (w.Ceiling == nil) || (w.Floor == nil) {
+		return true
+	}
+	return (w.Ceiling.Bottom < w.Floor.Top)
+}
+
+func (w *Wall) IsContinuous() bool {
+	return !w.IsSplit() && (w.Ceiling.FaceTexture == w.Floor.FaceTexture) && (w.Ceiling.FaceLight == w.Floor.FaceLight) && (w.Ceiling.FaceMapping == w.Floor.FaceMapping) && (w.Ceiling.Mirror == w.Floor.Mirror)
+}
+
+func (w *Wall) IsFrontFacing(camera *scene.Camera) bool {
+	return w.isFrontFacing(camera.X(), camera.Z())
+}
+
+// isFrontFacing returns whether the specified point is in front of the
+// wall, using the same test as IsFrontFacing.
+func (w *Wall) isFrontFacing(x, z float32) bool {
+	deltaX := w.RightEdgeX - w.LeftEdgeX
+	deltaZ := w.RightEdgeZ - w.LeftEdgeZ
+	return deltaZ*(w.RightEdgeX-x) < deltaX*(w.RightEdgeZ-z)
+}
+
+// Distance returns the signed distance from the specified point to the
+// line on which the wall lies. Positive values indicate that the point
+// is in front of the wall.
+func (w *Wall) Distance(x, z float32) float32 {
+	deltaX := w.RightEdgeX - w.LeftEdgeX
+	deltaZ := w.RightEdgeZ - w.LeftEdgeZ
+	return (deltaX*(w.RightEdgeZ-z) - deltaZ*(w.RightEdgeX-x)) / w.Length
+}
+
+// Normal returns the unit vector that is perpendicular to the wall and
+// points to its front side.
+func (w *Wall) Normal() (float32, float32) {
+	deltaX := w.RightEdgeX - w.LeftEdgeX
+	deltaZ := w.RightEdgeZ - w.LeftEdgeZ
+	return deltaZ / w.Length, -deltaX / w.Length
+}
+
+// ClosestPoint returns the point on the wall that is closest to the
+// specified point.
+func (w *Wall) ClosestPoint(x, z float32) (float32, float32) {
+	deltaX := w.RightEdgeX - w.LeftEdgeX
+	deltaZ := w.RightEdgeZ - w.LeftEdgeZ
+	lengthSqr := deltaX*deltaX + deltaZ*deltaZ
+	if lengthSqr == 0.0 {
+		return w.LeftEdgeX, w.LeftEdgeZ
+	}
+	t := ((x-w.LeftEdgeX)*deltaX + (z-w.LeftEdgeZ)*deltaZ) / lengthSqr
+	t = float32(math.Max(0.0, math.Min(1.0, float64(t))))
+	return w.LeftEdgeX + deltaX*t, w.LeftEdgeZ + deltaZ*t
+}
+
+// Obstructs returns whether the extrusions of the wall block the vertical
+// range between top and bottom.
+func (w *Wall) Obstructs(top, bottom float32) bool {
+	if w.HasCeilingExtrusion() && (top < w.Ceiling.Bottom) {
+		return true
+	}
+	if w.HasFloorExtrusion() && (bottom > w.Floor.Top) {
+		return true
+	}
+	return false
+}
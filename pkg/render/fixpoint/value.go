@@ -1,3 +1,5 @@
+// Package fixpoint provides fixed-point arithmetic for the inner
+// rendering loops.
 package fixpoint
 
 const precisionBits = 12
@@ -0,0 +1,63 @@
+package graphics
+
+import (
+	"math"
+
+	"github.com/mokiat/softgfx/pkg/render/fixpoint"
+)
+
+// Animation changes a Texture over time by cycling it through a number of
+// frames, by scrolling it, or both. Surfaces keep referencing the same
+// Texture, whose contents are replaced in place, so no allocations are
+// needed in order to animate it.
+type Animation struct {
+	// Texture is the texture that is animated.
+	Texture *Texture
+
+	// Frames holds the textures that are shown in place of the animated
+	// one, in order. If empty, the texture is not cycled.
+	Frames []*Texture
+
+	// FrameRate specifies how many frames are shown per second.
+	FrameRate float32
+
+	// ScrollU and ScrollV specify the speed, in texels per second,
+	// at which the texture scrolls.
+	ScrollU float32
+	ScrollV float32
+
+	frameTime float32
+	offsetU   float32
+	offsetV   float32
+}
+
+// Update advances the animation by the specified amount of time and
+// updates the texture accordingly.
+func (a *Animation) Update(elapsedSeconds float32) {
+	if len(a.Frames) > 0 && a.FrameRate > 0.0 {
+		duration := float32(len(a.Frames)) / a.FrameRate
+		a.frameTime = wrapFloat(a.frameTime+elapsedSeconds, duration)
+		frame := a.Frames[int(a.frameTime*a.FrameRate)%len(a.Frames)]
+		a.Texture.Width = frame.Width
+		a.Texture.Height = frame.Height
+		a.Texture.WidthMask = frame.WidthMask
+		a.Texture.HeightMask = frame.HeightMask
+		a.Texture.Texels = frame.Texels
+		a.Texture.Indices = frame.Indices
+		a.Texture.Mipmaps = frame.Mipmaps
+	}
+	a.offsetU = wrapFloat(a.offsetU+a.ScrollU*elapsedSeconds, float32(a.Texture.Width))
+	a.offsetV = wrapFloat(a.offsetV+a.ScrollV*elapsedSeconds, float32(a.Texture.Height))
+	a.Texture.OffsetU = fixpoint.FromFloat32(a.offsetU)
+	a.Texture.OffsetV = fixpoint.FromFloat32(a.offsetV)
+}
+
+// wrapFloat wraps the specified value to the range [0, period), which
+// keeps accumulated values from losing precision over time.
+func wrapFloat(value, period float32) float32 {
+	result := float32(math.Mod(float64(value), float64(period)))
+	if result < 0.0 {
+		result += period
+	}
+	return result
+}
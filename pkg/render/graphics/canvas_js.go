@@ -0,0 +1,67 @@
+// +build js
+
+package graphics
+
+import (
+	"fmt"
+	"syscall/js"
+	"unsafe"
+)
+
+// NewCanvasPlotter creates a new CanvasPlotter that draws onto the
+// HTML canvas element with the specified elementID.
+func NewCanvasPlotter(elementID string) (*CanvasPlotter, error) {
+	htmlWindow := js.Global().Get("window")
+	if htmlWindow.IsUndefined() {
+		return nil, fmt.Errorf("could not locate window element")
+	}
+
+	jsPlotterType := htmlWindow.Get("Plotter")
+	if jsPlotterType.IsUndefined() {
+		return nil, fmt.Errorf("could not locate js plotter type")
+	}
+
+	jsPlotter := jsPlotterType.New(elementID)
+	if jsPlotter.IsUndefined() {
+		return nil, fmt.Errorf("could not instantiate js plotter")
+	}
+
+	width := jsPlotter.Get("width").Int()
+	height := jsPlotter.Get("height").Int()
+	jsPlotterPixels := jsPlotter.Get("pixels")
+
+	return &CanvasPlotter{
+		pixelBuffer: pixelBuffer{
+			width:        width,
+			height:       height,
+			pixels:       make([]uint32, width*height),
+			shadingTable: NewShadingTable(White, Black),
+		},
+		jsPlotter:       jsPlotter,
+		jsPlotterPixels: jsPlotterPixels,
+	}, nil
+}
+
+var _ Plotter = (*CanvasPlotter)(nil)
+
+// CanvasPlotter is a Plotter that draws onto an HTML canvas.
+type CanvasPlotter struct {
+	pixelBuffer
+	jsPlotter       js.Value
+	jsPlotterPixels js.Value
+}
+
+func (p *CanvasPlotter) Flush() {
+	js.CopyBytesToJS(p.jsPlotterPixels, pixelBytes(p.pixels))
+	p.jsPlotter.Call("flush")
+}
+
+// pixelBytes returns the memory of the specified packed pixels as bytes.
+// Since wasm is little-endian, these are already in RGBA order, so no
+// conversion is needed.
+func pixelBytes(pixels []uint32) []byte {
+	if len(pixels) == 0 {
+		return nil
+	}
+	return unsafe.Slice((*byte)(unsafe.Pointer(&pixels[0])), len(pixels)*4)
+}
@@ -0,0 +1,19 @@
+// +build !js
+
+package graphics
+
+import "fmt"
+
+// NewCanvasPlotter is only supported in js builds, where an HTML canvas
+// is available. Use NewImagePlotter for native builds instead.
+func NewCanvasPlotter(elementID string) (*CanvasPlotter, error) {
+	return nil, fmt.Errorf("html canvas plotting is not supported outside js builds")
+}
+
+// CanvasPlotter is a Plotter that draws onto an HTML canvas.
+type CanvasPlotter struct {
+	pixelBuffer
+}
+
+func (p *CanvasPlotter) Flush() {
+}
@@ -0,0 +1,29 @@
+package graphics
+
+// colormapLevels is the number of shade levels in a colormap. Each level
+// covers a range of shade amounts, which keeps colormaps small enough to
+// be computed when they are first needed.
+const colormapLevels = 32
+
+// colormap maps a shade level and a palette index to the palette index of
+// the shaded color. It is the palette counterpart of a ShadingTable.
+type colormap [colormapLevels][256]byte
+
+// newColormap computes the colormap for the specified palette, where
+// colors are shaded according to the specified shading table.
+func newColormap(palette *Palette, table *ShadingTable) *colormap {
+	result := &colormap{}
+	for level := range result {
+		row := &table.rows[level*255/(colormapLevels-1)]
+		for index := 0; index < TransparentIndex; index++ {
+			result[level][index] = palette.Nearest(row.shadeColor(palette[index]))
+		}
+		result[level][TransparentIndex] = TransparentIndex
+	}
+	return result
+}
+
+// colormapLevel returns the colormap level for the specified shade amount.
+func colormapLevel(amount int) int {
+	return amount * colormapLevels / 256
+}
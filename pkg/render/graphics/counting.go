@@ -0,0 +1,85 @@
+package graphics
+
+// NewCountingPlotter creates a new CountingPlotter that forwards all
+// calls to the specified target Plotter.
+func NewCountingPlotter(target Plotter) *CountingPlotter {
+	return &CountingPlotter{
+		target: target,
+	}
+}
+
+var _ Plotter = (*CountingPlotter)(nil)
+
+// CountingPlotter is a Plotter that keeps track of the number of stripes
+// and pixels that have been plotted through it. It is useful for tests and
+// for profiling overdraw.
+type CountingPlotter struct {
+	target Plotter
+
+	verticalStripes   int
+	horizontalStripes int
+	pixels            int
+	flushes           int
+}
+
+func (p *CountingPlotter) Width() int {
+	return p.target.Width()
+}
+
+func (p *CountingPlotter) Height() int {
+	return p.target.Height()
+}
+
+func (p *CountingPlotter) PlotVerticalStripe(stripe VerticalStripe) {
+	p.verticalStripes++
+	p.pixels += stripe.Bottom - stripe.Top + 1
+	p.target.PlotVerticalStripe(stripe)
+}
+
+func (p *CountingPlotter) PlotAlphaVerticalStripe(stripe VerticalStripe) {
+	p.verticalStripes++
+	p.pixels += stripe.Bottom - stripe.Top + 1
+	p.target.PlotAlphaVerticalStripe(stripe)
+}
+
+func (p *CountingPlotter) PlotHorizontalStripe(stripe HorizontalStripe) {
+	p.horizontalStripes++
+	p.pixels += stripe.Right - stripe.Left + 1
+	p.target.PlotHorizontalStripe(stripe)
+}
+
+func (p *CountingPlotter) Flush() {
+	p.flushes++
+	p.target.Flush()
+}
+
+// VerticalStripes returns the number of vertical stripes that have
+// been plotted.
+func (p *CountingPlotter) VerticalStripes() int {
+	return p.verticalStripes
+}
+
+// HorizontalStripes returns the number of horizontal stripes that have
+// been plotted.
+func (p *CountingPlotter) HorizontalStripes() int {
+	return p.horizontalStripes
+}
+
+// Pixels returns the total number of pixels that have been plotted,
+// including ones that have been overdrawn.
+func (p *CountingPlotter) Pixels() int {
+	return p.pixels
+}
+
+// Flushes returns the number of times that Flush has been called.
+func (p *CountingPlotter) Flushes() int {
+	return p.flushes
+}
+
+// Reset sets all counters to zero.
+func (p *CountingPlotter) Reset() {
+	p.verticalStripes = 0
+	p.horizontalStripes = 0
+	p.pixels = 0
+	p.flushes = 0
+}
@@ -0,0 +1,30 @@
+package graphics
+
+// bayerMatrix holds the thresholds of a 4x4 ordered dithering pattern,
+// indexed by the screen Y and X coordinates. Each of the 16 thresholds
+// is used once, which spreads the pixels of a dithered area evenly.
+var bayerMatrix = [4][4]int{
+	{0, 8, 2, 10},
+	{12, 4, 14, 6},
+	{3, 11, 1, 9},
+	{15, 7, 13, 5},
+}
+
+// ShadeFractions is the number of fractions between two adjacent shade
+// amounts that stripes can specify through TexShadeFraction, which is
+// also the number of fractions that the dithering pattern can represent.
+const ShadeFractions = 16
+
+// ditherLevels returns the two adjacent levels, out of the specified
+// number of levels, between which the specified shade amount and
+// fraction lie, along with the fraction (0 to 15) by which they are
+// closer to the higher level. A pixel uses the higher level when its
+// threshold in the dithering pattern is below that fraction.
+func ditherLevels(amount, fraction, levels int) (low, high, levelFraction int) {
+	const maxShade = 255 * ShadeFractions
+	scaled := (amount*ShadeFractions + fraction) * (levels - 1)
+	low = scaled / maxShade
+	high = minInt(low+1, levels-1)
+	levelFraction = (scaled % maxShade) * ShadeFractions / maxShade
+	return low, high, levelFraction
+}
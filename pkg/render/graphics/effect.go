@@ -0,0 +1,247 @@
+package graphics
+
+import "math"
+
+// Effect modifies a frame after it has been rendered and before it is
+// presented. Effects are applied through a PostProcessPlotter.
+type Effect interface {
+	// Apply modifies the specified pixels, which make up a frame of the
+	// specified size in row-major order.
+	Apply(pixels []uint32, width, height int)
+}
+
+var (
+	// DamageTint is the tint color of a damage flash.
+	DamageTint = Color{R: 255, G: 0, B: 0}
+
+	// PickupTint is the tint color of an item pickup flash.
+	PickupTint = Color{R: 255, G: 200, B: 40}
+
+	// UnderwaterTint is the tint color of an underwater view.
+	UnderwaterTint = Color{R: 0, G: 80, B: 200}
+)
+
+const (
+	// minGamma is the lowest gamma value that can be configured on
+	// a ColorCorrection, which keeps the curve well defined.
+	minGamma = 0.1
+)
+
+// NewColorCorrection creates a new ColorCorrection with a gamma and
+// a brightness of one, which leave colors unchanged.
+func NewColorCorrection() *ColorCorrection {
+	c := &ColorCorrection{
+		gamma:      1.0,
+		brightness: 1.0,
+	}
+	c.updateTable()
+	return c
+}
+
+var _ Effect = (*ColorCorrection)(nil)
+
+// ColorCorrection is an Effect that adjusts the gamma and the brightness
+// of a frame, through a lookup table that is computed when either of
+// them changes.
+type ColorCorrection struct {
+	gamma      float32
+	brightness float32
+	table      shadingTableRow
+	identity   bool
+}
+
+// Gamma returns the gamma value of the correction.
+func (c *ColorCorrection) Gamma() float32 {
+	return c.gamma
+}
+
+// SetGamma configures the gamma value of the correction. Values above one
+// brighten the dark and medium tones, while values below one darken them.
+func (c *ColorCorrection) SetGamma(gamma float32) {
+	c.gamma = maxFloat(gamma, minGamma)
+	c.updateTable()
+}
+
+// Brightness returns the factor by which colors are multiplied.
+func (c *ColorCorrection) Brightness() float32 {
+	return c.brightness
+}
+
+// SetBrightness configures the factor by which colors are multiplied,
+// after gamma correction. Channels that exceed the maximum are clamped.
+func (c *ColorCorrection) SetBrightness(brightness float32) {
+	c.brightness = maxFloat(brightness, 0.0)
+	c.updateTable()
+}
+
+func (c *ColorCorrection) Apply(pixels []uint32, width, height int) {
+	if c.identity {
+		return
+	}
+	for i, pixel := range pixels {
+		pixels[i] = c.table.shade(pixel)
+	}
+}
+
+// updateTable recomputes the lookup table. The same mapping is used for
+// all color channels.
+func (c *ColorCorrection) updateTable() {
+	c.identity = true
+	for value := 0; value < 256; value++ {
+		corrected := math.Pow(float64(value)/255.0, 1.0/float64(c.gamma)) * float64(c.brightness)
+		result := byte(math.Round(math.Min(corrected, 1.0) * 255.0))
+		c.table.R[value] = uint32(result) << redShift
+		c.table.G[value] = uint32(result) << greenShift
+		c.table.B[value] = uint32(result) << blueShift
+		if result != byte(value) {
+			c.identity = false
+		}
+	}
+}
+
+var _ Effect = (*Tint)(nil)
+
+// Tint is an Effect that blends a frame with a color. It can be set to
+// a fixed amount, for example while underwater, or flashed, in which case
+// it fades out over time, for example after taking damage. Separate
+// Tint effects can be chained in order to combine both.
+type Tint struct {
+	color     Color
+	amount    float32
+	fadeSpeed float32
+}
+
+// Color returns the color with which frames are blended.
+func (t *Tint) Color() Color {
+	return t.color
+}
+
+// Amount returns the current amount of the tint, ranging from 0.0 (frames
+// are unchanged) to 1.0 (frames are replaced by the color).
+func (t *Tint) Amount() float32 {
+	return t.amount
+}
+
+// Set configures the tint to blend frames with the specified color by
+// the specified amount, until it is changed.
+func (t *Tint) Set(color Color, amount float32) {
+	t.color = color
+	t.amount = clampFloat(amount, 0.0, 1.0)
+	t.fadeSpeed = 0.0
+}
+
+// Flash configures the tint to blend frames with the specified color,
+// starting at the specified amount, which fades to zero over the
+// specified duration. Update needs to be called for it to fade.
+func (t *Tint) Flash(color Color, amount, fadeSeconds float32) {
+	t.Set(color, amount)
+	if fadeSeconds > 0.0 {
+		t.fadeSpeed = t.amount / fadeSeconds
+	} else {
+		t.amount = 0.0
+	}
+}
+
+// Clear removes the tint.
+func (t *Tint) Clear() {
+	t.Set(t.color, 0.0)
+}
+
+// Update advances the fading of a flash by the specified amount of time.
+func (t *Tint) Update(elapsedSeconds float32) {
+	t.amount = maxFloat(t.amount-t.fadeSpeed*elapsedSeconds, 0.0)
+}
+
+func (t *Tint) Apply(pixels []uint32, width, height int) {
+	alpha := int(t.amount * 255.0)
+	if alpha == 0 {
+		return
+	}
+	color := t.color.packed()
+	for i, pixel := range pixels {
+		pixels[i] = blendPixel(pixel, color, alpha)
+	}
+}
+
+var _ Effect = (*Scanlines)(nil)
+
+// Scanlines is an Effect that darkens every other row of a frame, which
+// mimics the look of a CRT display.
+type Scanlines struct {
+	// Intensity specifies how much the rows are darkened, ranging from
+	// 0.0 (not at all) to 1.0 (fully black).
+	Intensity float32
+}
+
+func (s *Scanlines) Apply(pixels []uint32, width, height int) {
+	factor := uint32((1.0 - clampFloat(s.Intensity, 0.0, 1.0)) * 256.0)
+	if factor == 256 {
+		return
+	}
+	for y := 1; y < height; y += 2 {
+		row := pixels[y*width : (y+1)*width]
+		for x, pixel := range row {
+			row[x] = scalePixel(pixel, factor)
+		}
+	}
+}
+
+var _ Effect = (*Vignette)(nil)
+
+// Vignette is an Effect that darkens a frame towards its corners, which
+// mimics the curved screen of a CRT display. The darkening factor of each
+// pixel is computed when the frame size or the intensity changes.
+type Vignette struct {
+	// Intensity specifies how much the corners are darkened, ranging from
+	// 0.0 (not at all) to 1.0 (fully black).
+	Intensity float32
+
+	factors   []uint32
+	width     int
+	height    int
+	intensity float32
+}
+
+func (v *Vignette) Apply(pixels []uint32, width, height int) {
+	if v.Intensity <= 0.0 {
+		return
+	}
+	if (width != v.width) || (height != v.height) || (v.Intensity != v.intensity) {
+		v.updateFactors(width, height)
+	}
+	for i, pixel := range pixels {
+		pixels[i] = scalePixel(pixel, v.factors[i])
+	}
+}
+
+// updateFactors recomputes the darkening factors for the specified frame
+// size. The darkening grows with the fourth power of the distance from
+// the center, which keeps most of the frame unchanged.
+func (v *Vignette) updateFactors(width, height int) {
+	v.width = width
+	v.height = height
+	v.intensity = v.Intensity
+	if count := width * height; count != len(v.factors) {
+		v.factors = make([]uint32, count)
+	}
+	intensity := clampFloat(v.Intensity, 0.0, 1.0)
+	halfWidth := float32(width) / 2.0
+	halfHeight := float32(height) / 2.0
+	for y := 0; y < height; y++ {
+		dy := (float32(y) + 0.5 - halfHeight) / halfHeight
+		for x := 0; x < width; x++ {
+			dx := (float32(x) + 0.5 - halfWidth) / halfWidth
+			distance := (dx*dx + dy*dy) / 2.0 // 0.0 at the center and 1.0 at the corners
+			v.factors[y*width+x] = uint32((1.0 - intensity*distance*distance) * 256.0)
+		}
+	}
+}
+
+// scalePixel multiplies the color channels of the specified pixel by the
+// specified factor, ranging from 0 (black) to 256 (unchanged). The alpha
+// channel is kept.
+func scalePixel(pixel, factor uint32) uint32 {
+	redBlue := ((pixel & 0x00FF00FF) * factor >> 8) & 0x00FF00FF
+	green := ((pixel & 0x0000FF00) * factor >> 8) & 0x0000FF00
+	return redBlue | green | (pixel & alphaMask)
+}
@@ -0,0 +1,38 @@
+package graphics
+
+import "image"
+
+// NewImagePlotter creates a new ImagePlotter that renders into an
+// in-memory image with the specified resolution.
+func NewImagePlotter(width, height int) *ImagePlotter {
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	return &ImagePlotter{
+		pixelBuffer: pixelBuffer{
+			width:        width,
+			height:       height,
+			pixels:       make([]uint32, width*height),
+			shadingTable: NewShadingTable(White, Black),
+		},
+		image: img,
+	}
+}
+
+var _ Plotter = (*ImagePlotter)(nil)
+
+// ImagePlotter is a Plotter that renders into an in-memory image.
+type ImagePlotter struct {
+	pixelBuffer
+	image *image.RGBA
+}
+
+// Image returns the image that holds the frame that was last flushed.
+// The returned image is reused between frames, so it should be copied if
+// it needs to be retained.
+func (p *ImagePlotter) Image() *image.RGBA {
+	return p.image
+}
+
+// Flush unpacks the plotted pixels into the image.
+func (p *ImagePlotter) Flush() {
+	unpackPixels(p.image.Pix, p.pixels)
+}
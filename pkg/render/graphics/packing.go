@@ -0,0 +1,45 @@
+package graphics
+
+// Pixels and texels are packed into uint32 words, which allows them to be
+// copied with a single load and store. The red channel is kept in the
+// least significant byte, followed by the green, blue and alpha channels.
+// On little-endian architectures, which include wasm, this gives the
+// words the same memory layout as RGBA bytes.
+const (
+	redShift   = 0
+	greenShift = 8
+	blueShift  = 16
+	alphaShift = 24
+
+	// alphaMask selects the alpha channel of a packed pixel.
+	alphaMask = 0xFF << alphaShift
+)
+
+// packRGBA packs the specified color channels into a single word.
+func packRGBA(r, g, b, a byte) uint32 {
+	return uint32(r)<<redShift | uint32(g)<<greenShift | uint32(b)<<blueShift | uint32(a)<<alphaShift
+}
+
+// unpackRGBA returns the color channels of the specified packed pixel.
+func unpackRGBA(pixel uint32) (r, g, b, a byte) {
+	return byte(pixel >> redShift), byte(pixel >> greenShift), byte(pixel >> blueShift), byte(pixel >> alphaShift)
+}
+
+// packPixels packs the RGBA bytes of src into the words of dst.
+func packPixels(dst []uint32, src []byte) {
+	for i := range dst {
+		dst[i] = packRGBA(src[i*4+0], src[i*4+1], src[i*4+2], src[i*4+3])
+	}
+}
+
+// unpackPixels unpacks the words of src into the RGBA bytes of dst.
+func unpackPixels(dst []byte, src []uint32) {
+	for i, pixel := range src {
+		dst[i*4+0], dst[i*4+1], dst[i*4+2], dst[i*4+3] = unpackRGBA(pixel)
+	}
+}
+
+// packed returns the specified color as an opaque packed pixel.
+func (c Color) packed() uint32 {
+	return packRGBA(c.R, c.G, c.B, 255)
+}
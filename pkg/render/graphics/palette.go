@@ -0,0 +1,250 @@
+package graphics
+
+import "sort"
+
+// TransparentIndex is the palette index of fully transparent texels.
+// Palettes created through NewPalette hold at most 255 colors, so that
+// none of them uses this index.
+const TransparentIndex = 255
+
+// paletteShadeAmounts are the shade amounts at which the colors of the
+// textures are sampled when creating a palette, so that the palette has
+// suitable colors for the colormaps.
+var paletteShadeAmounts = [...]int{0, 64, 128, 192}
+
+// paletteTextureWeight is the total weight of the texels of each texture
+// when creating a palette.
+const paletteTextureWeight = 1 << 20
+
+// Palette holds the colors that palette indices refer to.
+type Palette [256]Color
+
+// NewPalette creates a Palette that approximates the colors of the
+// specified textures, as shaded by the specified shading table, using
+// the median cut algorithm. Each texture has the same influence on the
+// palette, regardless of its size, so that textures that cover small
+// areas, such as the sky, keep their colors. Mipmaps and transparent
+// texels are not taken into account.
+func NewPalette(textures []*Texture, table *ShadingTable) *Palette {
+	histogram := make(map[Color]int)
+	for _, texture := range textures {
+		weight := maxInt(1, paletteTextureWeight/(texture.Width*texture.Height))
+		for _, texel := range texture.Texels {
+			if isTransparentTexel(byte(texel >> alphaShift)) {
+				continue
+			}
+			for _, amount := range paletteShadeAmounts {
+				r, g, b, _ := unpackRGBA(table.rows[amount].shade(texel))
+				histogram[Color{R: r, G: g, B: b}] += weight
+			}
+		}
+	}
+
+	colors := make([]paletteColor, 0, len(histogram))
+	for color, count := range histogram {
+		colors = append(colors, paletteColor{Color: color, count: count})
+	}
+	// map iteration is random, so sort the colors to have stable results
+	sort.Slice(colors, func(i, j int) bool {
+		return colorKey(colors[i].Color) < colorKey(colors[j].Color)
+	})
+
+	boxes := []paletteBox{newPaletteBox(colors)}
+	for len(boxes) < TransparentIndex {
+		index := worstBox(boxes)
+		if index < 0 {
+			break
+		}
+		low, high := boxes[index].split()
+		boxes[index] = low
+		boxes = append(boxes, high)
+	}
+
+	palette := &Palette{}
+	for i, box := range boxes {
+		palette[i] = box.average
+	}
+	return palette
+}
+
+// Nearest returns the index of the palette color that is closest to the
+// specified color. TransparentIndex is never returned.
+func (p *Palette) Nearest(color Color) byte {
+	bestIndex := 0
+	bestDistance := -1
+	for index := 0; index < TransparentIndex; index++ {
+		distance := colorDistance(p[index], color)
+		if bestDistance < 0 || distance < bestDistance {
+			bestIndex = index
+			bestDistance = distance
+		}
+	}
+	return byte(bestIndex)
+}
+
+// Blend returns a copy of the palette, where all colors are mixed with
+// the specified color by the specified amount, ranging from 0.0 (colors
+// are unchanged) to 1.0 (colors are replaced). It allows for effects,
+// such as damage flashes, through PalettePlotter.SetPalette.
+func (p *Palette) Blend(color Color, amount float32) Palette {
+	var result Palette
+	for index, original := range p {
+		result[index] = Color{
+			R: blendColorChannel(original.R, color.R, amount),
+			G: blendColorChannel(original.G, color.G, amount),
+			B: blendColorChannel(original.B, color.B, amount),
+		}
+	}
+	return result
+}
+
+// paletteColor is a unique color of the textures from which a palette
+// is created, along with the number of texels that have it.
+type paletteColor struct {
+	Color
+	count int
+}
+
+// paletteBox is a group of colors that is represented by a single
+// palette color, which is their weighted average.
+type paletteBox struct {
+	colors     []paletteColor
+	average    Color
+	distortion float64
+}
+
+func newPaletteBox(colors []paletteColor) paletteBox {
+	var r, g, b, total int
+	for _, color := range colors {
+		r += int(color.R) * color.count
+		g += int(color.G) * color.count
+		b += int(color.B) * color.count
+		total += color.count
+	}
+	average := Black
+	if total > 0 {
+		average = Color{R: byte(r / total), G: byte(g / total), B: byte(b / total)}
+	}
+	var distortion float64
+	for _, color := range colors {
+		distortion += float64(colorDistance(color.Color, average)) * float64(color.count)
+	}
+	return paletteBox{
+		colors:     colors,
+		average:    average,
+		distortion: distortion,
+	}
+}
+
+// channelRange returns the difference between the largest and the
+// smallest value of the specified color channel in the box.
+func (b paletteBox) channelRange(channel int) int {
+	minValue, maxValue := 255, 0
+	for _, color := range b.colors {
+		value := colorChannel(color.Color, channel)
+		minValue = minInt(minValue, value)
+		maxValue = maxInt(maxValue, value)
+	}
+	return maxValue - minValue
+}
+
+// split divides the box along the channel with the widest range, so that
+// both halves hold roughly the same number of texels.
+func (b paletteBox) split() (paletteBox, paletteBox) {
+	channel, widestRange := 0, -1
+	for candidate := 0; candidate < 3; candidate++ {
+		if channelRange := b.channelRange(candidate); channelRange > widestRange {
+			channel, widestRange = candidate, channelRange
+		}
+	}
+	sort.SliceStable(b.colors, func(i, j int) bool {
+		return colorChannel(b.colors[i].Color, channel) < colorChannel(b.colors[j].Color, channel)
+	})
+	total := 0
+	for _, color := range b.colors {
+		total += color.count
+	}
+	median := 1
+	for count := b.colors[0].count; median < len(b.colors)-1 && count < total/2; median++ {
+		count += b.colors[median].count
+	}
+	return newPaletteBox(b.colors[:median]), newPaletteBox(b.colors[median:])
+}
+
+// worstBox returns the index of the box whose colors are represented
+// the least accurately by its average, based on the squared color
+// distances of its texels. If no box can be split, the returned index
+// is negative.
+func worstBox(boxes []paletteBox) int {
+	bestIndex, bestDistortion := -1, 0.0
+	for index, box := range boxes {
+		if len(box.colors) >= 2 && box.distortion > bestDistortion {
+			bestIndex, bestDistortion = index, box.distortion
+		}
+	}
+	return bestIndex
+}
+
+func colorChannel(color Color, channel int) int {
+	switch channel {
+	case 0:
+		return int(color.R)
+	case 1:
+		return int(color.G)
+	default:
+		return int(color.B)
+	}
+}
+
+func colorKey(color Color) int {
+	return int(color.R)<<16 | int(color.G)<<8 | int(color.B)
+}
+
+func colorDistance(a, b Color) int {
+	dr := int(a.R) - int(b.R)
+	dg := int(a.G) - int(b.G)
+	db := int(a.B) - int(b.B)
+	return dr*dr + dg*dg + db*db
+}
+
+func blendColorChannel(original, color byte, amount float32) byte {
+	return byte(float32(original)*(1.0-amount) + float32(color)*amount)
+}
+
+// isTransparentTexel returns whether a texel with the specified alpha
+// value is considered transparent when quantized to a palette, where
+// texels can only be fully transparent or fully opaque.
+func isTransparentTexel(alpha byte) bool {
+	return alpha < 128
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+func maxFloat(a, b float32) float32 {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+func clampFloat(value, min, max float32) float32 {
+	if value < min {
+		return min
+	}
+	if value > max {
+		return max
+	}
+	return value
+}
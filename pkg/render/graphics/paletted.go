@@ -0,0 +1,202 @@
+package graphics
+
+// NewPalettePlotter creates a new PalettePlotter that plots palette
+// indices and expands them into the pixels of the specified target when
+// flushed. Textures are expected to be quantized to the specified
+// palette. Any that are not are quantized when they are first plotted.
+func NewPalettePlotter(target PixelPlotter, palette *Palette) *PalettePlotter {
+	p := &PalettePlotter{
+		target:       target,
+		palette:      palette,
+		shadingTable: NewShadingTable(White, Black),
+		colormaps:    make(map[*ShadingTable]*colormap),
+	}
+	p.SetPalette(*palette)
+	p.fitTarget()
+	return p
+}
+
+var _ Plotter = (*PalettePlotter)(nil)
+
+// PalettePlotter is a Plotter that works with 8-bit palette indices,
+// similar to classic software renderers. Shading is performed through
+// colormaps, which are computed from shading tables when they are first
+// used. Texels are either fully transparent or fully opaque.
+type PalettePlotter struct {
+	target        PixelPlotter
+	width         int
+	height        int
+	indices       []byte
+	palette       *Palette
+	displayPixels [256]uint32
+	shadingTable  *ShadingTable
+	colormaps     map[*ShadingTable]*colormap
+
+	lastShadingTable *ShadingTable
+	lastColormap     *colormap
+}
+
+func (p *PalettePlotter) Width() int {
+	return p.width
+}
+
+func (p *PalettePlotter) Height() int {
+	return p.height
+}
+
+// SetPalette configures the palette that is used to expand the indices
+// into pixels when flushed. The textures and the colormaps keep using
+// the original palette, which makes palette effects, such as damage
+// flashes, cheap. See Palette.Blend.
+func (p *PalettePlotter) SetPalette(palette Palette) {
+	for index, color := range palette {
+		p.displayPixels[index] = color.packed()
+	}
+}
+
+// colormap returns the colormap for the specified shading table of
+// a stripe, falling back to the shading table of the plotter.
+func (p *PalettePlotter) colormap(table *ShadingTable) *colormap {
+	if table == nil {
+		table = p.shadingTable
+	}
+	if table == p.lastShadingTable {
+		return p.lastColormap
+	}
+	result, ok := p.colormaps[table]
+	if !ok {
+		result = newColormap(p.palette, table)
+		p.colormaps[table] = result
+	}
+	p.lastShadingTable = table
+	p.lastColormap = result
+	return result
+}
+
+// textureIndices returns the palette indices of the specified texture.
+// Textures that have not been quantized yet, such as ones that were
+// loaded after the palette was created, are quantized to the palette of
+// the plotter first.
+func (p *PalettePlotter) textureIndices(texture *Texture) []byte {
+	if texture.Indices == nil {
+		texture.quantize(p.palette, make(map[Color]byte))
+	}
+	return texture.Indices
+}
+
+func (p *PalettePlotter) PlotVerticalStripe(stripe VerticalStripe) {
+	indexOffset := stripe.Top*p.width + stripe.X
+
+	texture, mipLevel := stripe.Texture.mipmap(stripe.DeltaV)
+	u := ((stripe.TopU + stripe.Texture.OffsetU.Floor()) >> mipLevel) & texture.WidthMask
+	v := (stripe.TopV + stripe.Texture.OffsetV) >> mipLevel
+	deltaV := stripe.DeltaV >> mipLevel
+
+	texels := p.textureIndices(texture)
+	texelBaseOffset := u * texture.Height
+	heightMask := texture.HeightMask
+	colormapRows := p.ditheredColormapRows(stripe.ShadingTable, stripe.TexShadeAmount, stripe.TexShadeFraction, stripe.Dither, func(i int) int {
+		return bayerMatrix[i][stripe.X&3]
+	})
+
+	for y := stripe.Top; y <= stripe.Bottom; y++ {
+		p.indices[indexOffset] = colormapRows[y&3][texels[texelBaseOffset+v.Floor()&heightMask]]
+		indexOffset += p.width
+		v += deltaV
+	}
+}
+
+func (p *PalettePlotter) PlotAlphaVerticalStripe(stripe VerticalStripe) {
+	indexOffset := stripe.Top*p.width + stripe.X
+
+	texture, mipLevel := stripe.Texture.mipmap(stripe.DeltaV)
+	u := ((stripe.TopU + stripe.Texture.OffsetU.Floor()) >> mipLevel) & texture.WidthMask
+	v := (stripe.TopV + stripe.Texture.OffsetV) >> mipLevel
+	deltaV := stripe.DeltaV >> mipLevel
+
+	texels := p.textureIndices(texture)
+	texelBaseOffset := u * texture.Height
+	heightMask := texture.HeightMask
+	colormapRows := p.ditheredColormapRows(stripe.ShadingTable, stripe.TexShadeAmount, stripe.TexShadeFraction, stripe.Dither, func(i int) int {
+		return bayerMatrix[i][stripe.X&3]
+	})
+
+	for y := stripe.Top; y <= stripe.Bottom; y++ {
+		if index := texels[texelBaseOffset+v.Floor()&heightMask]; index != TransparentIndex {
+			p.indices[indexOffset] = colormapRows[y&3][index]
+		}
+		indexOffset += p.width
+		v += deltaV
+	}
+}
+
+func (p *PalettePlotter) PlotHorizontalStripe(stripe HorizontalStripe) {
+	indexOffset := stripe.Y*p.width + stripe.Left
+
+	texture, mipLevel := stripe.Texture.mipmap(maxDelta(stripe.DeltaU, stripe.DeltaV))
+	u := (stripe.LeftU + stripe.Texture.OffsetU) >> mipLevel
+	v := (stripe.LeftV + stripe.Texture.OffsetV) >> mipLevel
+	deltaU := stripe.DeltaU >> mipLevel
+	deltaV := stripe.DeltaV >> mipLevel
+
+	texels := p.textureIndices(texture)
+	widthMask := texture.WidthMask
+	heightMask := texture.HeightMask
+	height := texture.Height
+	colormapRows := p.ditheredColormapRows(stripe.ShadingTable, stripe.TexShadeAmount, stripe.TexShadeFraction, stripe.Dither, func(i int) int {
+		return bayerMatrix[stripe.Y&3][i]
+	})
+
+	for x := stripe.Left; x <= stripe.Right; x++ {
+		texelU := u.Floor() & widthMask
+		texelV := v.Floor() & heightMask
+		p.indices[indexOffset] = colormapRows[x&3][texels[texelU*height+texelV]]
+
+		indexOffset++
+		u += deltaU
+		v += deltaV
+	}
+}
+
+// ditheredColormapRows returns the colormap rows for the specified shade
+// amount and fraction, for each of the four positions along a stripe that make up a
+// period of the dithering pattern. The threshold function returns the
+// threshold of the dithering pattern at each of them. Without dithering,
+// all rows are the same.
+func (p *PalettePlotter) ditheredColormapRows(table *ShadingTable, amount, fraction int, dither bool, threshold func(i int) int) [4]*[256]byte {
+	colormap := p.colormap(table)
+	if !dither {
+		row := &colormap[colormapLevel(amount)]
+		return [4]*[256]byte{row, row, row, row}
+	}
+	low, high, fraction := ditherLevels(amount, fraction, colormapLevels)
+	var rows [4]*[256]byte
+	for i := range rows {
+		rows[i] = &colormap[low]
+		if threshold(i) < fraction {
+			rows[i] = &colormap[high]
+		}
+	}
+	return rows
+}
+
+// fitTarget resizes the indices to match the size of the target.
+func (p *PalettePlotter) fitTarget() {
+	p.width = p.target.Width()
+	p.height = p.target.Height()
+	if count := p.width * p.height; count != len(p.indices) {
+		p.indices = make([]byte, count)
+	}
+}
+
+// Flush expands the plotted indices into the pixels of the target,
+// based on the display palette, and flushes the target. If the size of
+// the target changes as a result, the plotter is resized as well.
+func (p *PalettePlotter) Flush() {
+	pixels := p.target.Pixels()
+	for i, index := range p.indices {
+		pixels[i] = p.displayPixels[index]
+	}
+	p.target.Flush()
+	p.fitTarget()
+}
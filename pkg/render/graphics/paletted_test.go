@@ -0,0 +1,67 @@
+package graphics
+
+import "testing"
+
+func TestPalettePlotterQuantizesTextures(t *testing.T) {
+	red := Color{R: 255, G: 0, B: 0}
+	blue := Color{R: 0, G: 0, B: 255}
+	quantized := newSolidTexture(t, red)
+	palette := NewPalette([]*Texture{quantized, newSolidTexture(t, blue)}, NewShadingTable(White, Black))
+	quantized.Quantize(palette)
+
+	// The texture is plotted without having been quantized in advance,
+	// as is the case for textures that are loaded later on.
+	texture := newSolidTexture(t, blue)
+	target := NewImagePlotter(2, 2)
+	plotter := NewPalettePlotter(target, palette)
+	plotter.PlotVerticalStripe(VerticalStripe{
+		X:       0,
+		Top:     0,
+		Bottom:  1,
+		Texture: texture,
+	})
+	plotter.PlotHorizontalStripe(HorizontalStripe{
+		Y:       1,
+		Left:    0,
+		Right:   1,
+		Texture: quantized,
+	})
+	plotter.Flush()
+
+	if texture.Indices == nil {
+		t.Fatalf("expected texture to be quantized")
+	}
+	image := target.Image()
+	expected := []struct {
+		X     int
+		Y     int
+		Color Color
+	}{
+		{X: 0, Y: 0, Color: blue},
+		{X: 0, Y: 1, Color: red},
+		{X: 1, Y: 1, Color: red},
+	}
+	for _, pixel := range expected {
+		rgba := image.RGBAAt(pixel.X, pixel.Y)
+		if color := (Color{R: rgba.R, G: rgba.G, B: rgba.B}); color != pixel.Color {
+			t.Errorf("expected color %+v at %d,%d, got %+v", pixel.Color, pixel.X, pixel.Y, color)
+		}
+	}
+}
+
+// newSolidTexture creates a 4x4 opaque texture of the specified color.
+func newSolidTexture(t *testing.T, color Color) *Texture {
+	t.Helper()
+	texels := make([]byte, 4*4*4)
+	for i := 0; i < len(texels); i += 4 {
+		texels[i+0] = color.R
+		texels[i+1] = color.G
+		texels[i+2] = color.B
+		texels[i+3] = 255
+	}
+	texture, err := NewTexture(4, 4, texels)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return texture
+}
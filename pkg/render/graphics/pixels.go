@@ -0,0 +1,214 @@
+package graphics
+
+import "github.com/mokiat/softgfx/pkg/render/fixpoint"
+
+// pixelBuffer holds packed pixels in row-major order and implements the
+// stripe plotting routines that are shared by all plotters.
+type pixelBuffer struct {
+	width        int
+	height       int
+	pixels       []uint32
+	shadingTable *ShadingTable
+}
+
+// ditheredShadingRows returns the rows of the shading table of a stripe
+// for the specified shade amount and fraction, for each of the four
+// positions along the stripe that make up a period of the dithering
+// pattern, and whether they differ at all. When they do not, a simpler
+// plotting loop can be used. The threshold function returns the threshold
+// of the dithering pattern at each of the positions. If the stripe has
+// no shading table, the one of the buffer is used.
+func (p *pixelBuffer) ditheredShadingRows(table *ShadingTable, amount, fraction int, dither bool, threshold func(i int) int) ([4]*shadingTableRow, bool) {
+	if table == nil {
+		table = p.shadingTable
+	}
+	if !dither || fraction == 0 {
+		row := &table.rows[amount]
+		return [4]*shadingTableRow{row, row, row, row}, false
+	}
+	low, high, fraction := ditherLevels(amount, fraction, len(table.rows))
+	var rows [4]*shadingTableRow
+	for i := range rows {
+		rows[i] = &table.rows[low]
+		if threshold(i) < fraction {
+			rows[i] = &table.rows[high]
+		}
+	}
+	return rows, low != high
+}
+
+func (p *pixelBuffer) Width() int {
+	return p.width
+}
+
+func (p *pixelBuffer) Height() int {
+	return p.height
+}
+
+// Pixels returns the packed pixels of the buffer in row-major order.
+func (p *pixelBuffer) Pixels() []uint32 {
+	return p.pixels
+}
+
+func (p *pixelBuffer) PlotVerticalStripe(stripe VerticalStripe) {
+	pixelOffset := stripe.Top*p.width + stripe.X
+
+	texture, mipLevel := stripe.Texture.mipmap(stripe.DeltaV)
+	u := ((stripe.TopU + stripe.Texture.OffsetU.Floor()) >> mipLevel) & texture.WidthMask
+	v := (stripe.TopV + stripe.Texture.OffsetV) >> mipLevel
+	deltaV := stripe.DeltaV >> mipLevel
+
+	column := texture.Texels[u*texture.Height : (u+1)*texture.Height]
+	heightMask := texture.HeightMask
+	shadingRows, dithered := p.ditheredShadingRows(stripe.ShadingTable, stripe.TexShadeAmount, stripe.TexShadeFraction, stripe.Dither, func(i int) int {
+		return bayerMatrix[i][stripe.X&3]
+	})
+
+	if !dithered {
+		shadingRow := shadingRows[0]
+		for y := stripe.Top; y <= stripe.Bottom; y++ {
+			p.pixels[pixelOffset] = shadingRow.shade(column[v.Floor()&heightMask])
+			pixelOffset += p.width
+			v += deltaV
+		}
+		return
+	}
+
+	// The dithering pattern repeats every four pixels, so the shading rows
+	// of each of them are picked in advance and the loop is unrolled.
+	row0 := shadingRows[(stripe.Top+0)&3]
+	row1 := shadingRows[(stripe.Top+1)&3]
+	row2 := shadingRows[(stripe.Top+2)&3]
+	row3 := shadingRows[(stripe.Top+3)&3]
+	y := stripe.Top
+	for ; y+3 <= stripe.Bottom; y += 4 {
+		p.pixels[pixelOffset] = row0.shade(column[v.Floor()&heightMask])
+		pixelOffset += p.width
+		v += deltaV
+		p.pixels[pixelOffset] = row1.shade(column[v.Floor()&heightMask])
+		pixelOffset += p.width
+		v += deltaV
+		p.pixels[pixelOffset] = row2.shade(column[v.Floor()&heightMask])
+		pixelOffset += p.width
+		v += deltaV
+		p.pixels[pixelOffset] = row3.shade(column[v.Floor()&heightMask])
+		pixelOffset += p.width
+		v += deltaV
+	}
+	for ; y <= stripe.Bottom; y++ {
+		p.pixels[pixelOffset] = shadingRows[y&3].shade(column[v.Floor()&heightMask])
+		pixelOffset += p.width
+		v += deltaV
+	}
+}
+
+func (p *pixelBuffer) PlotAlphaVerticalStripe(stripe VerticalStripe) {
+	pixelOffset := stripe.Top*p.width + stripe.X
+
+	texture, mipLevel := stripe.Texture.mipmap(stripe.DeltaV)
+	u := ((stripe.TopU + stripe.Texture.OffsetU.Floor()) >> mipLevel) & texture.WidthMask
+	v := (stripe.TopV + stripe.Texture.OffsetV) >> mipLevel
+	deltaV := stripe.DeltaV >> mipLevel
+
+	column := texture.Texels[u*texture.Height : (u+1)*texture.Height]
+	heightMask := texture.HeightMask
+	shadingRows, _ := p.ditheredShadingRows(stripe.ShadingTable, stripe.TexShadeAmount, stripe.TexShadeFraction, stripe.Dither, func(i int) int {
+		return bayerMatrix[i][stripe.X&3]
+	})
+
+	for y := stripe.Top; y <= stripe.Bottom; y++ {
+		shadingRow := shadingRows[y&3]
+		texel := column[v.Floor()&heightMask]
+		switch alpha := int(texel >> alphaShift); alpha {
+		case 0:
+			// fully transparent, keep existing pixel
+		case 255:
+			p.pixels[pixelOffset] = shadingRow.shade(texel)&^alphaMask | p.pixels[pixelOffset]&alphaMask
+		default:
+			p.pixels[pixelOffset] = blendPixel(p.pixels[pixelOffset], shadingRow.shade(texel), alpha)
+		}
+		pixelOffset += p.width
+		v += deltaV
+	}
+}
+
+func (p *pixelBuffer) PlotHorizontalStripe(stripe HorizontalStripe) {
+	texture, mipLevel := stripe.Texture.mipmap(maxDelta(stripe.DeltaU, stripe.DeltaV))
+	u := (stripe.LeftU + stripe.Texture.OffsetU) >> mipLevel
+	v := (stripe.LeftV + stripe.Texture.OffsetV) >> mipLevel
+	deltaU := stripe.DeltaU >> mipLevel
+	deltaV := stripe.DeltaV >> mipLevel
+
+	texels := texture.Texels
+	widthMask := texture.WidthMask
+	heightMask := texture.HeightMask
+	height := texture.Height
+	shadingRows, dithered := p.ditheredShadingRows(stripe.ShadingTable, stripe.TexShadeAmount, stripe.TexShadeFraction, stripe.Dither, func(i int) int {
+		return bayerMatrix[stripe.Y&3][i]
+	})
+	pixels := p.pixels[stripe.Y*p.width+stripe.Left : stripe.Y*p.width+stripe.Right+1]
+
+	if !dithered {
+		shadingRow := shadingRows[0]
+		for x := range pixels {
+			pixels[x] = shadingRow.shade(texels[(u.Floor()&widthMask)*height+(v.Floor()&heightMask)])
+			u += deltaU
+			v += deltaV
+		}
+		return
+	}
+
+	// The dithering pattern repeats every four pixels, so the shading rows
+	// of each of them are picked in advance and the loop is unrolled.
+	row0 := shadingRows[(stripe.Left+0)&3]
+	row1 := shadingRows[(stripe.Left+1)&3]
+	row2 := shadingRows[(stripe.Left+2)&3]
+	row3 := shadingRows[(stripe.Left+3)&3]
+	x := 0
+	for ; x+3 < len(pixels); x += 4 {
+		pixels[x+0] = row0.shade(texels[(u.Floor()&widthMask)*height+(v.Floor()&heightMask)])
+		u += deltaU
+		v += deltaV
+		pixels[x+1] = row1.shade(texels[(u.Floor()&widthMask)*height+(v.Floor()&heightMask)])
+		u += deltaU
+		v += deltaV
+		pixels[x+2] = row2.shade(texels[(u.Floor()&widthMask)*height+(v.Floor()&heightMask)])
+		u += deltaU
+		v += deltaV
+		pixels[x+3] = row3.shade(texels[(u.Floor()&widthMask)*height+(v.Floor()&heightMask)])
+		u += deltaU
+		v += deltaV
+	}
+	for ; x < len(pixels); x++ {
+		pixels[x] = shadingRows[(stripe.Left+x)&3].shade(texels[(u.Floor()&widthMask)*height+(v.Floor()&heightMask)])
+		u += deltaU
+		v += deltaV
+	}
+}
+
+// maxDelta returns the one of the specified deltas that has the larger
+// magnitude, which determines the mip level of a horizontal stripe.
+func maxDelta(a, b fixpoint.Value) fixpoint.Value {
+	if a < 0 {
+		a = -a
+	}
+	if b < 0 {
+		b = -b
+	}
+	if a > b {
+		return a
+	}
+	return b
+}
+
+// blendPixel mixes the color channels of the source pixel into the
+// destination one based on the specified alpha amount (0 to 255). The
+// alpha channel of the destination pixel is kept. The red and blue
+// channels are blended together, since there is enough space between
+// them for the intermediate results.
+func blendPixel(dst, src uint32, alpha int) uint32 {
+	weight := uint32(alpha + alpha>>7) // 0 to 256
+	redBlue := ((dst&0x00FF00FF)*(256-weight) + (src&0x00FF00FF)*weight) >> 8
+	green := ((dst&0x0000FF00)*(256-weight) + (src&0x0000FF00)*weight) >> 8
+	return (redBlue & 0x00FF00FF) | (green & 0x0000FF00) | (dst & alphaMask)
+}
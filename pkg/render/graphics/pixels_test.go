@@ -0,0 +1,317 @@
+package graphics
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/mokiat/softgfx/pkg/render/fixpoint"
+)
+
+const (
+	benchmarkWidth  = 640
+	benchmarkHeight = 480
+)
+
+func TestPixelBufferStripes(t *testing.T) {
+	tint := Color{R: 255, G: 200, B: 120}
+	fog := Color{R: 40, G: 60, B: 90}
+	background := Color{R: 10, G: 220, B: 30}
+	table := NewShadingTable(tint, fog)
+
+	// The texels have random colors and cycle through being transparent,
+	// translucent and opaque.
+	random := rand.New(rand.NewSource(1))
+	texels := make([]byte, testTextureSize*testTextureSize*4)
+	random.Read(texels)
+	for i := 3; i < len(texels); i += 4 {
+		texels[i] = [...]byte{0, 128, 255}[(i/4)%3]
+	}
+	texture, err := NewTexture(testTextureSize, testTextureSize, texels)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// Each stripe samples the texels along its line one by one and has
+	// its own shade amount.
+	amount := func(stripe int) int {
+		return stripe * 255 / (testTextureSize - 1)
+	}
+	// expected returns the color that a pixel is expected to have when
+	// the texel of the same coordinates is shaded by the specified amount
+	// and blended onto the background based on the alpha.
+	expected := func(x, y, amount int, blend bool) Color {
+		offset := (x*testTextureSize + y) * 4
+		texel := texels[offset : offset+4]
+		fogAmount := float32(amount) / 255.0
+		shaded := Color{
+			R: shadeChannel(texel[0], tint.R, fog.R, fogAmount),
+			G: shadeChannel(texel[1], tint.G, fog.G, fogAmount),
+			B: shadeChannel(texel[2], tint.B, fog.B, fogAmount),
+		}
+		if !blend {
+			return shaded
+		}
+		alpha := int(texel[3])
+		return Color{
+			R: referenceBlend(background.R, shaded.R, alpha),
+			G: referenceBlend(background.G, shaded.G, alpha),
+			B: referenceBlend(background.B, shaded.B, alpha),
+		}
+	}
+	newPlotter := func() *ImagePlotter {
+		plotter := NewImagePlotter(testTextureSize, testTextureSize)
+		for i := range plotter.pixels {
+			plotter.pixels[i] = background.packed()
+		}
+		return plotter
+	}
+
+	t.Run("vertical", func(t *testing.T) {
+		plotter := newPlotter()
+		for x := 0; x < testTextureSize; x++ {
+			plotter.PlotVerticalStripe(testVerticalStripe(texture, table, x, amount(x)))
+		}
+		assertPixels(t, plotter, func(x, y int) Color {
+			return expected(x, y, amount(x), false)
+		})
+	})
+
+	t.Run("alpha vertical", func(t *testing.T) {
+		plotter := newPlotter()
+		for x := 0; x < testTextureSize; x++ {
+			plotter.PlotAlphaVerticalStripe(testVerticalStripe(texture, table, x, amount(x)))
+		}
+		assertPixels(t, plotter, func(x, y int) Color {
+			return expected(x, y, amount(x), true)
+		})
+	})
+
+	t.Run("horizontal", func(t *testing.T) {
+		plotter := newPlotter()
+		for y := 0; y < testTextureSize; y++ {
+			plotter.PlotHorizontalStripe(HorizontalStripe{
+				Y:              y,
+				Left:           0,
+				Right:          testTextureSize - 1,
+				LeftU:          fixpoint.FromInt(0),
+				LeftV:          fixpoint.FromInt(y),
+				DeltaU:         fixpoint.FromInt(1),
+				DeltaV:         fixpoint.FromInt(0),
+				Texture:        texture,
+				TexShadeAmount: amount(y),
+				ShadingTable:   table,
+			})
+		}
+		assertPixels(t, plotter, func(x, y int) Color {
+			return expected(x, y, amount(y), false)
+		})
+	})
+}
+
+func TestPixelBufferDithering(t *testing.T) {
+	const amount = 100
+	table := NewShadingTable(White, Black)
+	texture := newSolidTexture(t, White)
+	low := table.rows[amount].shadeColor(White)
+	high := table.rows[amount+1].shadeColor(White)
+
+	// Half of the way to the next shade amount, half of the pixels of the
+	// dithering pattern use the higher one.
+	assertDithered := func(t *testing.T, plotter *ImagePlotter) {
+		t.Helper()
+		plotter.Flush()
+		image := plotter.Image()
+		highCount := 0
+		for y := 0; y < plotter.Height(); y++ {
+			for x := 0; x < plotter.Width(); x++ {
+				pixel := image.RGBAAt(x, y)
+				switch color := (Color{R: pixel.R, G: pixel.G, B: pixel.B}); color {
+				case low:
+				case high:
+					highCount++
+				default:
+					t.Fatalf("expected color %+v or %+v at %d,%d, got %+v", low, high, x, y, color)
+				}
+			}
+		}
+		if highCount != 8 {
+			t.Errorf("expected 8 pixels of the higher shade amount, got %d", highCount)
+		}
+	}
+
+	t.Run("vertical", func(t *testing.T) {
+		plotter := NewImagePlotter(4, 4)
+		for x := 0; x < 4; x++ {
+			plotter.PlotVerticalStripe(VerticalStripe{
+				X:                x,
+				Top:              0,
+				Bottom:           3,
+				Texture:          texture,
+				TexShadeAmount:   amount,
+				TexShadeFraction: ShadeFractions / 2,
+				Dither:           true,
+			})
+		}
+		assertDithered(t, plotter)
+	})
+
+	t.Run("horizontal", func(t *testing.T) {
+		plotter := NewImagePlotter(4, 4)
+		for y := 0; y < 4; y++ {
+			plotter.PlotHorizontalStripe(HorizontalStripe{
+				Y:                y,
+				Left:             0,
+				Right:            3,
+				Texture:          texture,
+				TexShadeAmount:   amount,
+				TexShadeFraction: ShadeFractions / 2,
+				Dither:           true,
+			})
+		}
+		assertDithered(t, plotter)
+	})
+}
+
+func BenchmarkPlotVerticalStripe(b *testing.B) {
+	benchmarkPlotVerticalStripe(b, false)
+}
+
+func BenchmarkPlotDitheredVerticalStripe(b *testing.B) {
+	benchmarkPlotVerticalStripe(b, true)
+}
+
+func benchmarkPlotVerticalStripe(b *testing.B, dither bool) {
+	plotter := NewImagePlotter(benchmarkWidth, benchmarkHeight)
+	texture := newBenchmarkTexture(b, 255)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for x := 0; x < benchmarkWidth; x++ {
+			plotter.PlotVerticalStripe(benchmarkVerticalStripe(texture, x, dither))
+		}
+	}
+}
+
+func BenchmarkPlotAlphaVerticalStripe(b *testing.B) {
+	plotter := NewImagePlotter(benchmarkWidth, benchmarkHeight)
+	texture := newBenchmarkTexture(b, 128)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for x := 0; x < benchmarkWidth; x++ {
+			plotter.PlotAlphaVerticalStripe(benchmarkVerticalStripe(texture, x, false))
+		}
+	}
+}
+
+func BenchmarkPlotHorizontalStripe(b *testing.B) {
+	benchmarkPlotHorizontalStripe(b, false)
+}
+
+func BenchmarkPlotDitheredHorizontalStripe(b *testing.B) {
+	benchmarkPlotHorizontalStripe(b, true)
+}
+
+func benchmarkPlotHorizontalStripe(b *testing.B, dither bool) {
+	plotter := NewImagePlotter(benchmarkWidth, benchmarkHeight)
+	texture := newBenchmarkTexture(b, 255)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for y := 0; y < benchmarkHeight; y++ {
+			plotter.PlotHorizontalStripe(HorizontalStripe{
+				Y:                y,
+				Left:             0,
+				Right:            benchmarkWidth - 1,
+				LeftU:            fixpoint.FromInt(y),
+				LeftV:            fixpoint.FromInt(0),
+				DeltaU:           fixpoint.FromFloat32(0.3),
+				DeltaV:           fixpoint.FromFloat32(0.7),
+				Texture:          texture,
+				TexShadeAmount:   y % 256,
+				TexShadeFraction: y % ShadeFractions,
+				Dither:           dither,
+			})
+		}
+	}
+}
+
+// benchmarkVerticalStripe returns a stripe that spans the full height of
+// the benchmark plotter, with a shade amount that varies by column, as it
+// would for a wall that is viewed at an angle.
+func benchmarkVerticalStripe(texture *Texture, x int, dither bool) VerticalStripe {
+	return VerticalStripe{
+		X:                x,
+		Top:              0,
+		Bottom:           benchmarkHeight - 1,
+		TopU:             x,
+		TopV:             fixpoint.FromInt(0),
+		DeltaV:           fixpoint.FromFloat32(0.6),
+		Texture:          texture,
+		TexShadeAmount:   x % 256,
+		TexShadeFraction: x % ShadeFractions,
+		Dither:           dither,
+	}
+}
+
+// newBenchmarkTexture creates a 64x64 texture with random colors and
+// the specified alpha value, along with its mipmaps.
+func newBenchmarkTexture(b *testing.B, alpha byte) *Texture {
+	b.Helper()
+	random := rand.New(rand.NewSource(1))
+	newTexture := func(size int) *Texture {
+		texels := make([]byte, size*size*4)
+		random.Read(texels)
+		for i := 3; i < len(texels); i += 4 {
+			texels[i] = alpha
+		}
+		texture, err := NewTexture(size, size, texels)
+		if err != nil {
+			b.Fatal(err)
+		}
+		return texture
+	}
+	texture := newTexture(64)
+	for size := 32; size >= 1; size /= 2 {
+		texture.Mipmaps = append(texture.Mipmaps, newTexture(size))
+	}
+	return texture
+}
+
+// testTextureSize is the size of the textures and plotters of the tests.
+const testTextureSize = 8
+
+func testVerticalStripe(texture *Texture, table *ShadingTable, x, amount int) VerticalStripe {
+	return VerticalStripe{
+		X:              x,
+		Top:            0,
+		Bottom:         testTextureSize - 1,
+		TopU:           x,
+		TopV:           fixpoint.FromInt(0),
+		DeltaV:         fixpoint.FromInt(1),
+		Texture:        texture,
+		TexShadeAmount: amount,
+		ShadingTable:   table,
+	}
+}
+
+// referenceBlend blends a single color channel, the same way the
+// plotters blend translucent texels.
+func referenceBlend(dst, src byte, alpha int) byte {
+	weight := alpha + alpha>>7
+	return byte((int(dst)*(256-weight) + int(src)*weight) >> 8)
+}
+
+// assertPixels checks that the colors of all pixels of the specified
+// plotter match the expected ones.
+func assertPixels(t *testing.T, plotter *ImagePlotter, expected func(x, y int) Color) {
+	t.Helper()
+	plotter.Flush()
+	image := plotter.Image()
+	for y := 0; y < plotter.Height(); y++ {
+		for x := 0; x < plotter.Width(); x++ {
+			pixel := image.RGBAAt(x, y)
+			actual := Color{R: pixel.R, G: pixel.G, B: pixel.B}
+			if expectedColor := expected(x, y); actual != expectedColor {
+				t.Errorf("expected color %+v at %d,%d, got %+v", expectedColor, x, y, actual)
+			}
+		}
+	}
+}
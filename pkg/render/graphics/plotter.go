@@ -0,0 +1,43 @@
+// Package graphics provides textures and the plotters that draw
+// textured stripes onto various targets.
+package graphics
+
+// Plotter represents a drawing target onto which textured stripes
+// can be plotted.
+//
+// The size of a Plotter may change when it is flushed, but not in
+// between, so users should query it at the start of each frame.
+type Plotter interface {
+	// Width returns the horizontal resolution of the drawing target.
+	Width() int
+
+	// Height returns the vertical resolution of the drawing target.
+	Height() int
+
+	// PlotVerticalStripe draws a textured vertical line.
+	PlotVerticalStripe(stripe VerticalStripe)
+
+	// PlotAlphaVerticalStripe draws a textured vertical line, where texels
+	// are blended with the existing pixels based on their alpha value.
+	// Fully transparent texels leave the existing pixels unchanged.
+	PlotAlphaVerticalStripe(stripe VerticalStripe)
+
+	// PlotHorizontalStripe draws a textured horizontal line.
+	PlotHorizontalStripe(stripe HorizontalStripe)
+
+	// Flush presents all of the stripes that have been plotted since
+	// the last call to Flush.
+	Flush()
+}
+
+// PixelPlotter is a Plotter that keeps its pixels in memory until they
+// are flushed, which allows other plotters to produce them directly.
+type PixelPlotter interface {
+	Plotter
+
+	// Pixels returns the pixels of the drawing target in row-major
+	// order. Each pixel is packed into a word, with the red channel in
+	// the least significant byte, followed by the green, blue and alpha
+	// channels.
+	Pixels() []uint32
+}
@@ -0,0 +1,70 @@
+package graphics
+
+// NewPostProcessPlotter creates a new PostProcessPlotter that plots onto
+// the specified target and applies the specified effects, in order, to
+// the pixels of the target before flushing it.
+func NewPostProcessPlotter(target PixelPlotter, effects ...Effect) *PostProcessPlotter {
+	return &PostProcessPlotter{
+		target:  target,
+		effects: effects,
+	}
+}
+
+var _ PixelPlotter = (*PostProcessPlotter)(nil)
+
+// PostProcessPlotter is a Plotter that applies a chain of effects, such
+// as color correction or screen tints, to each frame before it is
+// presented. Stripes are plotted directly onto the target, so the
+// effects do not add to the cost of plotting.
+type PostProcessPlotter struct {
+	target  PixelPlotter
+	effects []Effect
+}
+
+func (p *PostProcessPlotter) Width() int {
+	return p.target.Width()
+}
+
+func (p *PostProcessPlotter) Height() int {
+	return p.target.Height()
+}
+
+// Pixels returns the pixels of the target.
+func (p *PostProcessPlotter) Pixels() []uint32 {
+	return p.target.Pixels()
+}
+
+// Effects returns the effects that are applied, in order, when flushed.
+func (p *PostProcessPlotter) Effects() []Effect {
+	return p.effects
+}
+
+// SetEffects configures the effects that are applied, in order, when
+// flushed. It can be called between frames in order to enable or
+// disable effects.
+func (p *PostProcessPlotter) SetEffects(effects ...Effect) {
+	p.effects = effects
+}
+
+func (p *PostProcessPlotter) PlotVerticalStripe(stripe VerticalStripe) {
+	p.target.PlotVerticalStripe(stripe)
+}
+
+func (p *PostProcessPlotter) PlotAlphaVerticalStripe(stripe VerticalStripe) {
+	p.target.PlotAlphaVerticalStripe(stripe)
+}
+
+func (p *PostProcessPlotter) PlotHorizontalStripe(stripe HorizontalStripe) {
+	p.target.PlotHorizontalStripe(stripe)
+}
+
+// Flush applies the effects to the pixels of the target and flushes it.
+func (p *PostProcessPlotter) Flush() {
+	pixels := p.target.Pixels()
+	width := p.target.Width()
+	height := p.target.Height()
+	for _, effect := range p.effects {
+		effect.Apply(pixels, width, height)
+	}
+	p.target.Flush()
+}
@@ -0,0 +1,98 @@
+package graphics
+
+// NewScalingPlotter creates a new ScalingPlotter that renders at the
+// resolution of the specified target divided by the specified scale and
+// upscales the result onto the target when flushed.
+func NewScalingPlotter(target PixelPlotter, scale int) *ScalingPlotter {
+	p := &ScalingPlotter{
+		pixelBuffer: pixelBuffer{
+			shadingTable: NewShadingTable(White, Black),
+		},
+		target: target,
+	}
+	p.applyScale(scale)
+	return p
+}
+
+var _ PixelPlotter = (*ScalingPlotter)(nil)
+
+// ScalingPlotter is a Plotter with a lower resolution than its target,
+// which reduces the cost of rendering. Each pixel is upscaled to a square
+// of target pixels, using nearest neighbour sampling.
+type ScalingPlotter struct {
+	pixelBuffer
+	target       PixelPlotter
+	targetWidth  int
+	targetHeight int
+	scale        int
+	pendingScale int
+}
+
+// Scale returns the factor by which the resolution of the target is
+// divided.
+func (p *ScalingPlotter) Scale() int {
+	return p.scale
+}
+
+// SetScale configures the factor by which the resolution of the target
+// is divided. Since the size of a Plotter may only change when it is
+// flushed, the new scale takes effect after the next call to Flush.
+func (p *ScalingPlotter) SetScale(scale int) {
+	p.pendingScale = scale
+}
+
+// Flush upscales the plotted pixels onto the target and flushes it.
+func (p *ScalingPlotter) Flush() {
+	if p.scale > 1 {
+		p.upscale()
+	}
+	p.target.Flush()
+	targetResized := (p.target.Width() != p.targetWidth) || (p.target.Height() != p.targetHeight)
+	if targetResized || (p.pendingScale != p.scale) {
+		p.applyScale(p.pendingScale)
+	}
+}
+
+// applyScale resizes the pixel buffer according to the specified scale.
+// With a scale of one, the pixels of the target are plotted directly.
+func (p *ScalingPlotter) applyScale(scale int) {
+	if scale < 1 {
+		scale = 1
+	}
+	p.scale = scale
+	p.pendingScale = scale
+	p.targetWidth = p.target.Width()
+	p.targetHeight = p.target.Height()
+	if scale == 1 {
+		p.width = p.target.Width()
+		p.height = p.target.Height()
+		p.pixels = p.target.Pixels()
+		return
+	}
+	p.width = maxInt(1, p.target.Width()/scale)
+	p.height = maxInt(1, p.target.Height()/scale)
+	p.pixels = make([]uint32, p.width*p.height)
+}
+
+// upscale copies each pixel to a square of target pixels. Target pixels
+// beyond the scaled size, which are present when the size of the target
+// is not divisible by the scale, repeat the last row or column.
+func (p *ScalingPlotter) upscale() {
+	targetPixels := p.target.Pixels()
+	targetWidth := p.target.Width()
+	targetHeight := p.target.Height()
+
+	for targetY := 0; targetY < targetHeight; targetY++ {
+		y := minInt(targetY/p.scale, p.height-1)
+		targetRow := targetPixels[targetY*targetWidth : (targetY+1)*targetWidth]
+		if (targetY > 0) && (y == minInt((targetY-1)/p.scale, p.height-1)) {
+			// same source row as the previous target row
+			copy(targetRow, targetPixels[(targetY-1)*targetWidth:targetY*targetWidth])
+			continue
+		}
+		row := p.pixels[y*p.width : (y+1)*p.width]
+		for targetX := range targetRow {
+			targetRow[targetX] = row[minInt(targetX/p.scale, p.width-1)]
+		}
+	}
+}
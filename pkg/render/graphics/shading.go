@@ -0,0 +1,68 @@
+package graphics
+
+// Color represents an RGB color.
+type Color struct {
+	R byte
+	G byte
+	B byte
+}
+
+var (
+	// White is the tint color that leaves surfaces unchanged.
+	White = Color{R: 255, G: 255, B: 255}
+
+	// Black is the fog color that makes distant surfaces fade to black.
+	Black = Color{R: 0, G: 0, B: 0}
+)
+
+// NewShadingTable creates a new ShadingTable where colors are multiplied
+// by the tint color and fade towards the fog color as the shade amount
+// increases.
+func NewShadingTable(tint, fog Color) *ShadingTable {
+	table := &ShadingTable{}
+	for amount := range table.rows {
+		row := &table.rows[amount]
+		fogAmount := float32(amount) / 255.0
+		for color := 0; color < 256; color++ {
+			row.R[color] = uint32(shadeChannel(byte(color), tint.R, fog.R, fogAmount)) << redShift
+			row.G[color] = uint32(shadeChannel(byte(color), tint.G, fog.G, fogAmount)) << greenShift
+			row.B[color] = uint32(shadeChannel(byte(color), tint.B, fog.B, fogAmount)) << blueShift
+		}
+	}
+	return table
+}
+
+// ShadingTable is a lookup table that maps a shade amount (0 meaning no
+// shading and 255 meaning full shading) and a color channel value to the
+// resulting shaded color channel value. Each of the red, green and blue
+// channels has its own mapping.
+type ShadingTable struct {
+	rows [256]shadingTableRow
+}
+
+// shadingTableRow holds the shaded channel values for a single shade
+// amount. The values are already shifted to the positions of their
+// channels in a packed pixel, so that a shaded pixel is assembled from
+// the three lookups without unpacking and packing the channels.
+type shadingTableRow struct {
+	R [256]uint32
+	G [256]uint32
+	B [256]uint32
+}
+
+// shade returns the shaded version of the specified packed pixel. The
+// alpha channel is left unchanged.
+func (r *shadingTableRow) shade(pixel uint32) uint32 {
+	return r.R[byte(pixel>>redShift)] | r.G[byte(pixel>>greenShift)] | r.B[byte(pixel>>blueShift)] | pixel&alphaMask
+}
+
+// shadeColor returns the shaded version of the specified color.
+func (r *shadingTableRow) shadeColor(color Color) Color {
+	red, green, blue, _ := unpackRGBA(r.shade(color.packed()))
+	return Color{R: red, G: green, B: blue}
+}
+
+func shadeChannel(color, tint, fog byte, fogAmount float32) byte {
+	litColor := float32(color) * (float32(tint) / 255.0)
+	return byte(litColor*(1.0-fogAmount) + float32(fog)*fogAmount)
+}
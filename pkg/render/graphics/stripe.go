@@ -0,0 +1,60 @@
+package graphics
+
+import "github.com/mokiat/softgfx/pkg/render/fixpoint"
+
+type VerticalStripe struct {
+	X              int
+	Top            int
+	Bottom         int
+	TopU           int
+	TopV           fixpoint.Value
+	DeltaV         fixpoint.Value
+	Texture        *Texture
+	TexShadeAmount int
+
+	// ShadingTable, if set, is used instead of the default shading table
+	// of the Plotter. This allows regions to have their own lighting.
+	ShadingTable *ShadingTable
+
+	// TexShadeFraction is the fraction (0 to ShadeFractions-1) of the
+	// way from TexShadeAmount to the next shade amount. It only has an
+	// effect when the stripe is dithered.
+	TexShadeFraction int
+
+	// Dither specifies whether the shading is dithered between adjacent
+	// shade levels, which hides the bands where the shade level changes.
+	// Plotters with a shade level per shade amount dither between the
+	// two amounts that TexShadeFraction lies between, while the
+	// PalettePlotter, whose colormaps have fewer levels, dithers between
+	// the two colormap levels that the shade amount lies between.
+	Dither bool
+}
+
+type HorizontalStripe struct {
+	Y              int
+	Left           int
+	Right          int
+	LeftU          fixpoint.Value
+	LeftV          fixpoint.Value
+	DeltaU         fixpoint.Value
+	DeltaV         fixpoint.Value
+	Texture        *Texture
+	TexShadeAmount int
+
+	// ShadingTable, if set, is used instead of the default shading table
+	// of the Plotter. This allows regions to have their own lighting.
+	ShadingTable *ShadingTable
+
+	// TexShadeFraction is the fraction (0 to ShadeFractions-1) of the
+	// way from TexShadeAmount to the next shade amount. It only has an
+	// effect when the stripe is dithered.
+	TexShadeFraction int
+
+	// Dither specifies whether the shading is dithered between adjacent
+	// shade levels, which hides the bands where the shade level changes.
+	// Plotters with a shade level per shade amount dither between the
+	// two amounts that TexShadeFraction lies between, while the
+	// PalettePlotter, whose colormaps have fewer levels, dithers between
+	// the two colormap levels that the shade amount lies between.
+	Dither bool
+}
@@ -0,0 +1,108 @@
+package graphics
+
+import (
+	"fmt"
+	"math/bits"
+
+	"github.com/mokiat/softgfx/pkg/render/fixpoint"
+)
+
+// NewTexture creates a new Texture with the specified size and texels,
+// which are in column-major RGBA byte order. Both the width and the
+// height need to be powers of two.
+func NewTexture(width, height int, texels []byte) (*Texture, error) {
+	if !isPowerOfTwo(width) || !isPowerOfTwo(height) {
+		return nil, fmt.Errorf("texture size %dx%d is not a power of two", width, height)
+	}
+	if len(texels) != width*height*4 {
+		return nil, fmt.Errorf("texture has %d texel bytes but %d are expected", len(texels), width*height*4)
+	}
+	packedTexels := make([]uint32, width*height)
+	packPixels(packedTexels, texels)
+	return &Texture{
+		Width:      width,
+		Height:     height,
+		WidthMask:  width - 1,
+		HeightMask: height - 1,
+		Texels:     packedTexels,
+	}, nil
+}
+
+// Texture holds the texels of an image in column-major order, packed
+// into words. Since both dimensions are powers of two, texture
+// coordinates can be wrapped by applying the masks.
+type Texture struct {
+	Width      int
+	Height     int
+	WidthMask  int
+	HeightMask int
+	Texels     []uint32
+
+	// Indices holds the palette indices of the texels in column-major
+	// order. It is only set once the texture has been quantized, which
+	// a PalettePlotter does when the texture is first plotted, unless
+	// it has been done in advance.
+	Indices []byte
+
+	// Mipmaps holds progressively downscaled versions of the texture,
+	// each half the size of the previous one. The texture itself is
+	// considered to be mip level zero.
+	Mipmaps []*Texture
+
+	// OffsetU and OffsetV are added to all texture coordinates that
+	// are used to sample the texture, which allows it to be scrolled.
+	OffsetU fixpoint.Value
+	OffsetV fixpoint.Value
+}
+
+// Quantize computes the palette indices of the texture and of its
+// mipmaps, based on the specified palette. Texels that are mostly
+// transparent are assigned TransparentIndex, while the rest are
+// considered to be opaque.
+func (t *Texture) Quantize(palette *Palette) {
+	nearest := make(map[Color]byte)
+	t.quantize(palette, nearest)
+	for _, mipmap := range t.Mipmaps {
+		mipmap.quantize(palette, nearest)
+	}
+}
+
+func (t *Texture) quantize(palette *Palette, nearest map[Color]byte) {
+	t.Indices = make([]byte, t.Width*t.Height)
+	for i := range t.Indices {
+		r, g, b, a := unpackRGBA(t.Texels[i])
+		if isTransparentTexel(a) {
+			t.Indices[i] = TransparentIndex
+			continue
+		}
+		color := Color{R: r, G: g, B: b}
+		index, ok := nearest[color]
+		if !ok {
+			index = palette.Nearest(color)
+			nearest[color] = index
+		}
+		t.Indices[i] = index
+	}
+}
+
+// mipmap returns the most suitable mip level for a stripe that advances
+// by delta texels per pixel, along with the texture of that level.
+// Texture coordinates need to be shifted right by the level in order to
+// be used with the returned texture.
+func (t *Texture) mipmap(delta fixpoint.Value) (*Texture, int) {
+	if delta < 0 {
+		delta = -delta
+	}
+	level := bits.Len(uint(delta.Floor())) - 1
+	if level <= 0 {
+		return t, 0
+	}
+	if level > len(t.Mipmaps) {
+		level = len(t.Mipmaps)
+	}
+	return t.Mipmaps[level-1], level
+}
+
+func isPowerOfTwo(value int) bool {
+	return (value > 0) && (value&(value-1) == 0)
+}
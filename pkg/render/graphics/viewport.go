@@ -0,0 +1,61 @@
+package graphics
+
+// NewViewportPlotter creates a new ViewportPlotter that exposes the
+// rectangular area of the target Plotter, starting at position x, y
+// and with the specified size, as a standalone drawing target.
+// The area should be fully contained within the target.
+func NewViewportPlotter(target Plotter, x, y, width, height int) *ViewportPlotter {
+	return &ViewportPlotter{
+		target: target,
+		x:      x,
+		y:      y,
+		width:  width,
+		height: height,
+	}
+}
+
+var _ Plotter = (*ViewportPlotter)(nil)
+
+// ViewportPlotter is a Plotter that draws into a sub-area of another
+// Plotter. It can be used to tile multiple views onto a single target.
+type ViewportPlotter struct {
+	target Plotter
+	x      int
+	y      int
+	width  int
+	height int
+}
+
+func (p *ViewportPlotter) Width() int {
+	return p.width
+}
+
+func (p *ViewportPlotter) Height() int {
+	return p.height
+}
+
+func (p *ViewportPlotter) PlotVerticalStripe(stripe VerticalStripe) {
+	stripe.X += p.x
+	stripe.Top += p.y
+	stripe.Bottom += p.y
+	p.target.PlotVerticalStripe(stripe)
+}
+
+func (p *ViewportPlotter) PlotAlphaVerticalStripe(stripe VerticalStripe) {
+	stripe.X += p.x
+	stripe.Top += p.y
+	stripe.Bottom += p.y
+	p.target.PlotAlphaVerticalStripe(stripe)
+}
+
+func (p *ViewportPlotter) PlotHorizontalStripe(stripe HorizontalStripe) {
+	stripe.Y += p.y
+	stripe.Left += p.x
+	stripe.Right += p.x
+	p.target.PlotHorizontalStripe(stripe)
+}
+
+// Flush is a no-op. Since multiple viewports can share the same target,
+// it is the responsibility of the target owner to flush it.
+func (p *ViewportPlotter) Flush() {
+}
@@ -0,0 +1,336 @@
+// Package render provides a software renderer for BSP extrusion levels.
+//
+// The rendering itself is split across the graphics, scene and bsp
+// subpackages. This package ties them together for the common use cases.
+package render
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/mokiat/softgfx/pkg/data"
+	"github.com/mokiat/softgfx/pkg/render/bsp"
+	"github.com/mokiat/softgfx/pkg/render/graphics"
+	"github.com/mokiat/softgfx/pkg/render/scene"
+)
+
+// Option configures a Renderer.
+type Option func(r *scene.Renderer)
+
+// WithShadingFactor configures how quickly surfaces fade to black
+// as their distance from the camera increases.
+func WithShadingFactor(factor float32) Option {
+	return func(r *scene.Renderer) {
+		r.SetShadingFactor(factor)
+	}
+}
+
+// WithShadingTable configures the shading table that is used for all
+// surfaces that do not specify their own, for example one created
+// through LevelShadingTable.
+func WithShadingTable(table *graphics.ShadingTable) Option {
+	return func(r *scene.Renderer) {
+		r.SetShadingTable(table)
+	}
+}
+
+// WithDithering configures whether shading is dithered between adjacent
+// shade levels, which hides the bands where the shade level changes.
+func WithDithering(dithering bool) Option {
+	return func(r *scene.Renderer) {
+		r.SetDithering(dithering)
+	}
+}
+
+// NewRenderer creates a new Renderer that draws onto the specified
+// Plotter.
+func NewRenderer(plotter graphics.Plotter, opts ...Option) *Renderer {
+	sceneRenderer := scene.NewRenderer(plotter)
+	for _, opt := range opts {
+		opt(sceneRenderer)
+	}
+	renderer := &Renderer{
+		sceneRenderer: sceneRenderer,
+		bspRenderer:   bsp.NewRenderer(sceneRenderer),
+	}
+	sceneRenderer.SetViewRenderer(renderer.renderView)
+	return renderer
+}
+
+// Renderer renders BSP trees onto a Plotter.
+type Renderer struct {
+	sceneRenderer *scene.Renderer
+	bspRenderer   *bsp.Renderer
+
+	// root and sprites hold the scene of the frame that is being rendered,
+	// which is needed again for the reflections of mirrors.
+	root    *bsp.Wall
+	sprites []scene.Sprite
+}
+
+// SceneRenderer returns the underlying scene renderer.
+func (r *Renderer) SceneRenderer() *scene.Renderer {
+	return r.sceneRenderer
+}
+
+// BSPRenderer returns the underlying BSP renderer.
+func (r *Renderer) BSPRenderer() *bsp.Renderer {
+	return r.bspRenderer
+}
+
+// Render draws a complete frame of the BSP tree with the specified root
+// and of the specified sprites as seen from the specified camera.
+// The Plotter is not flushed, which is left to the caller.
+func (r *Renderer) Render(root *bsp.Wall, sprites []scene.Sprite, camera *scene.Camera) {
+	r.root = root
+	r.sprites = sprites
+	r.bspRenderer.Clear()
+	r.renderView(camera)
+}
+
+// renderView renders the scene of the current frame as seen from the
+// specified camera. The scene renderer calls it with reflected cameras
+// when it meets mirror faces, in which case the BSP traversal stops as
+// soon as the window of the mirror has been filled.
+func (r *Renderer) renderView(camera *scene.Camera) {
+	r.bspRenderer.RenderBSP(r.root, camera)
+	r.sceneRenderer.RenderTranslucent(r.sprites, camera)
+}
+
+// TextureLoader loads the texture with the specified name.
+type TextureLoader func(name string) (data.Texture, error)
+
+// Level holds a level that has been loaded for rendering.
+type Level struct {
+	// Root is the root wall of the BSP tree of the level.
+	Root *bsp.Wall
+
+	// Animations holds the texture animations of the level.
+	Animations []*graphics.Animation
+
+	// Textures holds all of the textures of the level, including the
+	// animation frames.
+	Textures []*graphics.Texture
+}
+
+// Animate advances all texture animations of the level by the specified
+// amount of time.
+func (l *Level) Animate(elapsedSeconds float32) {
+	for _, animation := range l.Animations {
+		animation.Update(elapsedSeconds)
+	}
+}
+
+// LoadLevel loads all of the textures referenced by the specified level
+// through loadTexture, sets up their animations and builds a BSP tree
+// from the level walls.
+func LoadLevel(level data.Level, loadTexture TextureLoader) (*Level, error) {
+	textures := make([]*graphics.Texture, len(level.Textures))
+	for i, textureName := range level.Textures {
+		texture, err := loadTexture(textureName)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load texture %q: %w", textureName, err)
+		}
+		textures[i], err = ConvertTexture(texture)
+		if err != nil {
+			return nil, fmt.Errorf("failed to convert texture %q: %w", textureName, err)
+		}
+	}
+
+	allTextures := append([]*graphics.Texture(nil), textures...)
+	animations, err := loadAnimations(level.Animations, textures)
+	if err != nil {
+		return nil, err
+	}
+	for _, animation := range animations {
+		allTextures = append(allTextures, animation.Texture)
+	}
+
+	rootWall := bsp.BuildTree(level, textures)
+	if rootWall == nil {
+		return nil, fmt.Errorf("level has no walls")
+	}
+	return &Level{
+		Root:       rootWall,
+		Animations: animations,
+		Textures:   allTextures,
+	}, nil
+}
+
+// loadAnimations creates the specified animations. Each animated texture
+// is replaced in the textures slice by a copy that the animation can
+// change, since the original texture may still be used elsewhere,
+// for example as a frame.
+func loadAnimations(levelAnimations []data.Animation, textures []*graphics.Texture) ([]*graphics.Animation, error) {
+	getTexture := func(index int) (*graphics.Texture, error) {
+		if index < 0 || index >= len(textures) {
+			return nil, fmt.Errorf("texture index %d is out of range", index)
+		}
+		return textures[index], nil
+	}
+
+	animations := make([]*graphics.Animation, len(levelAnimations))
+	animated := make(map[int]*graphics.Texture)
+	for i, levelAnimation := range levelAnimations {
+		texture, err := getTexture(levelAnimation.Texture)
+		if err != nil {
+			return nil, fmt.Errorf("invalid animation %d: %w", i, err)
+		}
+		if _, ok := animated[levelAnimation.Texture]; ok {
+			return nil, fmt.Errorf("invalid animation %d: texture %d is already animated", i, levelAnimation.Texture)
+		}
+		frames := make([]*graphics.Texture, len(levelAnimation.Frames))
+		for j, frameIndex := range levelAnimation.Frames {
+			if frames[j], err = getTexture(frameIndex); err != nil {
+				return nil, fmt.Errorf("invalid frame %d of animation %d: %w", j, i, err)
+			}
+		}
+		handle := *texture
+		animated[levelAnimation.Texture] = &handle
+		animations[i] = &graphics.Animation{
+			Texture:   &handle,
+			Frames:    frames,
+			FrameRate: levelAnimation.FrameRate,
+			ScrollU:   levelAnimation.ScrollU,
+			ScrollV:   levelAnimation.ScrollV,
+		}
+		// show the first frame before the animation is first advanced
+		animations[i].Update(0.0)
+	}
+	for index, handle := range animated {
+		textures[index] = handle
+	}
+	return animations, nil
+}
+
+// LevelShadingTable creates a shading table based on the tint and fog
+// colors of the specified level. Surfaces are not tinted and fade to
+// black, unless the level specifies otherwise.
+func LevelShadingTable(level data.Level) *graphics.ShadingTable {
+	tint := graphics.White
+	if level.Tint != nil {
+		tint = ConvertColor(*level.Tint)
+	}
+	fog := graphics.Black
+	if level.Fog != nil {
+		fog = ConvertColor(*level.Fog)
+	}
+	return graphics.NewShadingTable(tint, fog)
+}
+
+// LoadSprites creates sprites for all of the specified entities that
+// have a `sprite` property, which holds the name of the texture to be
+// loaded through loadTexture. The optional `width` and `height` properties
+// specify the size of the sprite and default to the size of the texture.
+// The optional `light` property specifies the light level of the sprite.
+func LoadSprites(entities []data.Entity, loadTexture TextureLoader) ([]scene.Sprite, error) {
+	textures := make(map[string]*graphics.Texture)
+	var sprites []scene.Sprite
+	for _, entity := range entities {
+		textureName, ok := entity.Properties["sprite"]
+		if !ok {
+			continue
+		}
+
+		texture, ok := textures[textureName]
+		if !ok {
+			original, err := loadTexture(textureName)
+			if err != nil {
+				return nil, fmt.Errorf("failed to load texture %q: %w", textureName, err)
+			}
+			texture, err = ConvertTexture(original)
+			if err != nil {
+				return nil, fmt.Errorf("failed to convert texture %q: %w", textureName, err)
+			}
+			textures[textureName] = texture
+		}
+
+		width, err := floatProperty(entity, "width", float32(texture.Width))
+		if err != nil {
+			return nil, err
+		}
+		height, err := floatProperty(entity, "height", float32(texture.Height))
+		if err != nil {
+			return nil, err
+		}
+		light, err := floatProperty(entity, "light", data.DefaultLight)
+		if err != nil {
+			return nil, err
+		}
+
+		sprites = append(sprites, scene.Sprite{
+			X:       entity.X,
+			Y:       entity.Y,
+			Z:       entity.Z,
+			Width:   width,
+			Height:  height,
+			Texture: texture,
+			Light:   light,
+		})
+	}
+	return sprites, nil
+}
+
+func floatProperty(entity data.Entity, name string, defaultValue float32) (float32, error) {
+	text, ok := entity.Properties[name]
+	if !ok {
+		return defaultValue, nil
+	}
+	value, err := strconv.ParseFloat(text, 32)
+	if err != nil {
+		return 0.0, fmt.Errorf("invalid %s %q of %q entity: %w", name, text, entity.Type, err)
+	}
+	return float32(value), nil
+}
+
+// SpriteTextures returns the distinct textures of the specified sprites.
+func SpriteTextures(sprites []scene.Sprite) []*graphics.Texture {
+	var textures []*graphics.Texture
+	seen := make(map[*graphics.Texture]bool)
+	for _, sprite := range sprites {
+		if !seen[sprite.Texture] {
+			seen[sprite.Texture] = true
+			textures = append(textures, sprite.Texture)
+		}
+	}
+	return textures
+}
+
+// QuantizeTextures creates a palette that approximates the colors of the
+// specified textures, as shaded by the specified shading table, and
+// quantizes the textures to it, so that they can be plotted by a
+// graphics.PalettePlotter.
+func QuantizeTextures(textures []*graphics.Texture, table *graphics.ShadingTable) *graphics.Palette {
+	palette := graphics.NewPalette(textures, table)
+	for _, texture := range textures {
+		texture.Quantize(palette)
+	}
+	return palette
+}
+
+// ConvertTexture converts a texture from the data format into one
+// that can be used for rendering.
+func ConvertTexture(original data.Texture) (*graphics.Texture, error) {
+	texture, err := graphics.NewTexture(original.Width, original.Height, original.Texels)
+	if err != nil {
+		return nil, err
+	}
+	for i, originalMipmap := range original.Mipmaps {
+		mipmap, err := graphics.NewTexture(originalMipmap.Width, originalMipmap.Height, originalMipmap.Texels)
+		if err != nil {
+			return nil, fmt.Errorf("invalid mipmap %d: %w", i+1, err)
+		}
+		texture.Mipmaps = append(texture.Mipmaps, mipmap)
+	}
+	return texture, nil
+}
+
+// ConvertColor converts a color from the data format into one
+// that can be used for rendering.
+func ConvertColor(original data.Color) graphics.Color {
+	return graphics.Color{
+		R: original.R,
+		G: original.G,
+		B: original.B,
+	}
+}
@@ -0,0 +1,195 @@
+// Package scene renders individual wall segments, along with their
+// floors and ceilings, as seen from a camera.
+package scene
+
+import "math"
+
+// DefaultFieldOfView is the horizontal field of view, in degrees, of new
+// cameras. On a canvas with a 4:3 aspect ratio, it results in a vertical
+// field of view of 90 degrees.
+var DefaultFieldOfView = float32(2.0 * math.Atan(4.0/3.0) * 180.0 / math.Pi)
+
+const (
+	// MinFieldOfView is the smallest supported field of view, in degrees.
+	MinFieldOfView float32 = 10.0
+
+	// MaxFieldOfView is the largest supported field of view, in degrees.
+	MaxFieldOfView float32 = 160.0
+)
+
+func NewCamera() *Camera {
+	camera := &Camera{
+		x:        0.0,
+		y:        0.0,
+		z:        0.0,
+		angle:    0.0,
+		angleCos: 1.0,
+		angleSin: 0.0,
+		skew:     0.0,
+	}
+	camera.SetFieldOfView(DefaultFieldOfView)
+	return camera
+}
+
+type Camera struct {
+	x        float32
+	y        float32
+	z        float32
+	angle    float32
+	angleCos float32
+	angleSin float32
+	skew     float32
+	fov      float32
+	fovTan   float32
+
+	// mirrored specifies that the view of the camera is flipped
+	// horizontally, as is the case for reflections in mirrors.
+	mirrored bool
+}
+
+func (c *Camera) X() float32 {
+	return c.x
+}
+
+func (c *Camera) Y() float32 {
+	return c.y
+}
+
+func (c *Camera) Z() float32 {
+	return c.z
+}
+
+func (c *Camera) SetPosition(x, y, z float32) {
+	c.x = x
+	c.y = y
+	c.z = z
+}
+
+func (c *Camera) SetRotation(angle float32) {
+	c.angle = angle
+	c.updateAngleCosSin()
+}
+
+func (c *Camera) Rotation() float32 {
+	return c.angle
+}
+
+func (c *Camera) SetSkew(skew float32) {
+	c.skew = skew
+}
+
+func (c *Camera) Skew() float32 {
+	return c.skew
+}
+
+// SetFieldOfView configures the horizontal field of view of the camera,
+// in degrees. The vertical field of view follows from the aspect ratio
+// of the drawing target. The value is clamped to the range between
+// MinFieldOfView and MaxFieldOfView.
+func (c *Camera) SetFieldOfView(fov float32) {
+	c.fov = float32(math.Max(float64(MinFieldOfView), math.Min(float64(MaxFieldOfView), float64(fov))))
+	c.fovTan = float32(math.Tan(math.Pi * (float64(c.fov) / 360.0)))
+}
+
+// FieldOfView returns the horizontal field of view of the camera,
+// in degrees.
+func (c *Camera) FieldOfView() float32 {
+	return c.fov
+}
+
+// ZoomIn narrows the field of view by the specified amount of degrees.
+func (c *Camera) ZoomIn(amount float32) {
+	c.SetFieldOfView(c.fov - amount)
+}
+
+// ZoomOut widens the field of view by the specified amount of degrees.
+func (c *Camera) ZoomOut(amount float32) {
+	c.SetFieldOfView(c.fov + amount)
+}
+
+func (c *Camera) MoveForward(amount float32) {
+	c.x -= c.angleSin * amount
+	c.z += c.angleCos * amount
+}
+
+func (c *Camera) MoveBackward(amount float32) {
+	c.x += c.angleSin * amount
+	c.z -= c.angleCos * amount
+}
+
+func (c *Camera) MoveLeft(amount float32) {
+	c.x -= c.angleCos * amount
+	c.z -= c.angleSin * amount
+}
+
+func (c *Camera) MoveRight(amount float32) {
+	c.x += c.angleCos * amount
+	c.z += c.angleSin * amount
+}
+
+func (c *Camera) MoveUp(amount float32) {
+	c.y -= amount
+}
+
+func (c *Camera) MoveDown(amount float32) {
+	c.y += amount
+}
+
+func (c *Camera) TurnLeft(amount float32) {
+	c.angle += amount
+	c.updateAngleCosSin()
+}
+
+func (c *Camera) TurnRight(amount float32) {
+	c.angle -= amount
+	c.updateAngleCosSin()
+}
+
+func (c *Camera) LookUp(amount float32) {
+	c.skew -= amount
+}
+
+func (c *Camera) LookDown(amount float32) {
+	c.skew += amount
+}
+
+// Mirrored returns whether the view of the camera is flipped horizontally,
+// which is the case for cameras created through Reflect.
+func (c *Camera) Mirrored() bool {
+	return c.mirrored
+}
+
+// Reflect returns a camera that sees what this camera would see in a
+// mirror that lies on the line through the specified points. The position
+// and direction of the camera are reflected across the line and its view
+// is flipped horizontally, so that the reflection is not seen from behind.
+func (c *Camera) Reflect(x1, z1, x2, z2 float32) Camera {
+	deltaX := float64(x2 - x1)
+	deltaZ := float64(z2 - z1)
+	lengthSqr := deltaX*deltaX + deltaZ*deltaZ
+	toX := float64(c.x - x1)
+	toZ := float64(c.z - z1)
+	alongLine := (toX*deltaX + toZ*deltaZ) / lengthSqr
+
+	reflection := *c
+	reflection.x = x1 + float32(2.0*alongLine*deltaX-toX)
+	reflection.z = z1 + float32(2.0*alongLine*deltaZ-toZ)
+	lineAngle := math.Atan2(deltaZ, deltaX) * 180.0 / math.Pi
+	reflection.SetRotation(float32(2.0*lineAngle) - c.angle - 180.0)
+	reflection.mirrored = !c.mirrored
+	return reflection
+}
+
+// viewSign returns the factor by which the view space X coordinates of the
+// camera are multiplied, which is -1.0 for mirrored cameras.
+func (c *Camera) viewSign() float32 {
+	if c.mirrored {
+		return -1.0
+	}
+	return 1.0
+}
+
+func (c *Camera) updateAngleCosSin() {
+	c.angleCos = float32(math.Cos(math.Pi * (float64(c.angle) / 180.0)))
+	c.angleSin = float32(math.Sin(math.Pi * (float64(c.angle) / 180.0)))
+}
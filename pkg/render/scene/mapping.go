@@ -0,0 +1,37 @@
+package scene
+
+import "math"
+
+// DefaultTextureMapping maps each world unit to a single texel.
+var DefaultTextureMapping = TextureMapping{
+	ScaleU: 1.0,
+	ScaleV: 1.0,
+}
+
+// TextureMapping specifies how texture coordinates are derived from world
+// coordinates. Texture coordinates are measured in texels and are equal
+// to the world coordinates multiplied by the scale, plus the offset.
+//
+// For segment faces, the U world coordinate is the distance along the
+// segment and the V world coordinate is the height. For ceilings and
+// floors, these are the X and Z world coordinates respectively.
+type TextureMapping struct {
+	OffsetU float32
+	OffsetV float32
+	ScaleU  float32
+	ScaleV  float32
+}
+
+// U returns the U texture coordinate for the specified U world coordinate.
+func (m TextureMapping) U(worldU float32) float32 {
+	return m.ScaleU*worldU + m.OffsetU
+}
+
+// V returns the V texture coordinate for the specified V world coordinate.
+func (m TextureMapping) V(worldV float32) float32 {
+	return m.ScaleV*worldV + m.OffsetV
+}
+
+func floorInt(value float32) int {
+	return int(math.Floor(float64(value)))
+}
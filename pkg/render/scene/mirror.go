@@ -0,0 +1,179 @@
+package scene
+
+const (
+	// maxMirrorDepth is the number of reflections that can be nested.
+	// Mirrors that would need to be reflected more times, for example
+	// when two mirrors face each other, show their face texture instead.
+	maxMirrorDepth = 2
+
+	// mirrorLineTolerance is the distance from the line of a mirror up to
+	// which geometry is considered to lie on the line. Such geometry is
+	// not rendered in the reflection, which excludes the mirror itself.
+	mirrorLineTolerance float32 = 0.01
+)
+
+// ViewRenderer renders the scene as seen from the specified camera, through
+// the RenderSegment, RenderTranslucentSegment and RenderTranslucent methods
+// of the Renderer. It is called by the Renderer in order to draw the
+// reflections of mirror faces.
+type ViewRenderer func(camera *Camera)
+
+// SetViewRenderer configures the function through which the reflections
+// of mirror faces are drawn. If it is nil, mirror faces show their
+// texture instead.
+func (r *Renderer) SetViewRenderer(viewRenderer ViewRenderer) {
+	r.viewRenderer = viewRenderer
+}
+
+// view holds the state of the view that is being rendered, which is either
+// the view of the frame camera or a reflection in a mirror.
+type view struct {
+	depth            int          // the number of reflections that led to the view
+	window           clipSnapshot // the clip state of the whole screen when the view started
+	occluderStart    int          // the index of the first occluder of the view
+	translucentStart int          // the index of the first translucent segment of the view
+	mirror           mirrorLine   // the line of the mirror, if depth is greater than zero
+}
+
+// mirrorLine is the line in world space on which a mirror lies. The
+// reflected camera is behind the line, so the geometry that is behind
+// it as well needs to be excluded from the reflection.
+type mirrorLine struct {
+	X  float32
+	Z  float32
+	DX float32 // normalized, such that the camera is at a positive distance
+	DZ float32
+}
+
+// newMirrorLine creates the line of the specified mirror segment, which
+// is seen by the specified camera. Both need to be in world space.
+func newMirrorLine(segment Segment, camera *Camera) mirrorLine {
+	deltaX := segment.RightX - segment.LeftX
+	deltaZ := segment.RightZ - segment.LeftZ
+	line := mirrorLine{
+		X:  segment.LeftX,
+		Z:  segment.LeftZ,
+		DX: deltaX / segment.Length,
+		DZ: deltaZ / segment.Length,
+	}
+	if line.Distance(camera.x, camera.z) < 0.0 {
+		line.DX = -line.DX
+		line.DZ = -line.DZ
+	}
+	return line
+}
+
+// Distance returns the signed distance from the specified point to the
+// line. Positive values indicate that the point is in front of the mirror.
+func (l mirrorLine) Distance(x, z float32) float32 {
+	return l.DX*(z-l.Z) - l.DZ*(x-l.X)
+}
+
+// Reflect returns the reflection of the specified camera in the mirror.
+func (l mirrorLine) Reflect(camera *Camera) Camera {
+	return camera.Reflect(l.X, l.Z, l.X+l.DX, l.Z+l.DZ)
+}
+
+// Clip cuts off the part of the specified segment that is behind the
+// line. It returns false if nothing remains of the segment.
+func (l mirrorLine) Clip(segment *Segment) bool {
+	leftDistance := l.Distance(segment.LeftX, segment.LeftZ)
+	rightDistance := l.Distance(segment.RightX, segment.RightZ)
+	if (leftDistance <= mirrorLineTolerance) && (rightDistance <= mirrorLineTolerance) {
+		return false
+	}
+	switch {
+	case leftDistance < 0.0:
+		ratio := leftDistance / (leftDistance - rightDistance)
+		segment.LeftX += (segment.RightX - segment.LeftX) * ratio
+		segment.LeftZ += (segment.RightZ - segment.LeftZ) * ratio
+		segment.OffsetU += segment.Length * ratio
+		segment.Length -= segment.Length * ratio
+	case rightDistance < 0.0:
+		ratio := rightDistance / (rightDistance - leftDistance)
+		segment.RightX += (segment.LeftX - segment.RightX) * ratio
+		segment.RightZ += (segment.LeftZ - segment.RightZ) * ratio
+		segment.Length -= segment.Length * ratio
+	}
+	return true
+}
+
+// clipToMirror prepares the specified world space segment for rendering
+// in the current view. Reflections exclude the parts of segments that are
+// behind the mirror. It returns false if nothing remains of the segment.
+func (r *Renderer) clipToMirror(segment *Segment) bool {
+	if r.view.depth == 0 {
+		return true
+	}
+	return r.view.mirror.Clip(segment)
+}
+
+// renderMirror renders the reflection that is seen in the specified mirror
+// face, which lies on the specified line. The reflection is limited to the
+// screen area of the face that has not been drawn yet, through the clip
+// arrays, and everything that is rendered through the ViewRenderer in the
+// meantime belongs to the reflection. The clip state is restored afterwards.
+// It returns false if the reflection cannot be rendered, in which case the
+// face needs to be drawn with its texture.
+func (r *Renderer) renderMirror(camera *Camera, line mirrorLine, face faceSurface) bool {
+	if (r.viewRenderer == nil) || (r.view.depth >= maxMirrorDepth) {
+		return false
+	}
+
+	parentView := r.view
+	openClipCount := r.openClipCount
+	occluderCount := len(r.occluders)
+	translucentCount := len(r.translucentSegments)
+	clip := r.snapshotClip(0, r.width-1)
+
+	if r.clipToFace(face) {
+		// The reflected cameras are stored per depth, since a pointer to
+		// a local variable would escape through the ViewRenderer.
+		reflection := &r.mirrorCameras[parentView.depth]
+		*reflection = line.Reflect(camera)
+		r.view = view{
+			depth:            parentView.depth + 1,
+			window:           r.snapshotClip(0, r.width-1),
+			occluderStart:    occluderCount,
+			translucentStart: translucentCount,
+			mirror:           line,
+		}
+		r.viewRenderer(reflection)
+		r.view = parentView
+	}
+
+	copy(r.topClipScreenY, r.snapshotTopClipScreenY[clip.offset:clip.offset+r.width])
+	copy(r.bottomClipScreenY, r.snapshotBottomClipScreenY[clip.offset:clip.offset+r.width])
+	r.openClipCount = openClipCount
+	r.snapshotTopClipScreenY = r.snapshotTopClipScreenY[:clip.offset]
+	r.snapshotBottomClipScreenY = r.snapshotBottomClipScreenY[:clip.offset]
+	r.occluders = r.occluders[:occluderCount]
+	r.translucentSegments = r.translucentSegments[:translucentCount]
+	return true
+}
+
+// clipToFace limits the clip arrays to the screen area of the specified
+// face that is still open, which is the window through which a reflection
+// is seen. It returns false if no part of the face is open.
+func (r *Renderer) clipToFace(face faceSurface) bool {
+	for x := 0; x < r.width; x++ {
+		if (x < face.LeftScreenX) || (x > face.RightScreenX) {
+			r.topClipScreenY[x] = 0
+			r.bottomClipScreenY[x] = -1
+		}
+	}
+
+	topScreenY := face.TopScreenY
+	bottomScreenY := face.BottomScreenY
+	r.openClipCount = 0
+	for x := face.LeftScreenX; x <= face.RightScreenX; x++ {
+		r.topClipScreenY[x] = maxInt(topScreenY.Floor(), r.topClipScreenY[x])
+		r.bottomClipScreenY[x] = minInt(bottomScreenY.Floor(), r.bottomClipScreenY[x])
+		if r.topClipScreenY[x] <= r.bottomClipScreenY[x] {
+			r.openClipCount++
+		}
+		topScreenY += face.TopScreenYDelta
+		bottomScreenY += face.BottomScreenYDelta
+	}
+	return r.openClipCount > 0
+}
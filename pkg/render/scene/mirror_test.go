@@ -0,0 +1,290 @@
+package scene
+
+import (
+	"math"
+	"testing"
+
+	"github.com/mokiat/softgfx/pkg/render/fixpoint"
+	"github.com/mokiat/softgfx/pkg/render/graphics"
+)
+
+func TestMirrorLine(t *testing.T) {
+	// A mirror along the X axis, seen from positive Z.
+	mirror := Segment{
+		LeftX:  0.0,
+		LeftZ:  0.0,
+		RightX: 10.0,
+		RightZ: 0.0,
+		Length: 10.0,
+	}
+
+	t.Run("faces the camera", func(t *testing.T) {
+		for _, cameraZ := range []float32{10.0, -10.0} {
+			camera := NewCamera()
+			camera.SetPosition(5.0, 0.0, cameraZ)
+			line := newMirrorLine(mirror, camera)
+			if distance := line.Distance(camera.X(), camera.Z()); distance <= 0.0 {
+				t.Errorf("expected camera at Z %f to be in front of the line, got distance %f", cameraZ, distance)
+			}
+		}
+	})
+
+	t.Run("reflects the camera", func(t *testing.T) {
+		camera := NewCamera()
+		camera.SetPosition(3.0, -20.0, 10.0)
+		camera.SetRotation(30.0)
+		line := newMirrorLine(mirror, camera)
+		reflection := line.Reflect(camera)
+		if !reflection.Mirrored() {
+			t.Errorf("expected reflection to be mirrored")
+		}
+		assertFloat(t, "reflection X", reflection.X(), 3.0)
+		assertFloat(t, "reflection Y", reflection.Y(), -20.0)
+		assertFloat(t, "reflection Z", reflection.Z(), -10.0)
+	})
+}
+
+func TestMirrorLineClip(t *testing.T) {
+	camera := NewCamera()
+	camera.SetPosition(5.0, 0.0, 10.0)
+	line := newMirrorLine(Segment{
+		LeftX:  0.0,
+		LeftZ:  0.0,
+		RightX: 10.0,
+		RightZ: 0.0,
+		Length: 10.0,
+	}, camera)
+
+	t.Run("keeps segment in front", func(t *testing.T) {
+		segment := Segment{LeftX: 0.0, LeftZ: 5.0, RightX: 10.0, RightZ: 20.0, Length: 18.03, OffsetU: 3.0}
+		expected := segment
+		if !line.Clip(&segment) {
+			t.Fatalf("expected segment to remain")
+		}
+		if segment != expected {
+			t.Errorf("expected segment %+v, got %+v", expected, segment)
+		}
+	})
+
+	t.Run("keeps segment touching the line", func(t *testing.T) {
+		segment := Segment{LeftX: 20.0, LeftZ: 0.0, RightX: 20.0, RightZ: 10.0, Length: 10.0}
+		expected := segment
+		if !line.Clip(&segment) {
+			t.Fatalf("expected segment to remain")
+		}
+		if segment != expected {
+			t.Errorf("expected segment %+v, got %+v", expected, segment)
+		}
+	})
+
+	t.Run("removes segment behind", func(t *testing.T) {
+		segment := Segment{LeftX: 0.0, LeftZ: -5.0, RightX: 10.0, RightZ: -20.0, Length: 18.03}
+		if line.Clip(&segment) {
+			t.Errorf("expected segment to be removed")
+		}
+	})
+
+	t.Run("removes segment on the line", func(t *testing.T) {
+		segment := Segment{LeftX: 2.0, LeftZ: 0.0, RightX: 8.0, RightZ: 0.005, Length: 6.0}
+		if line.Clip(&segment) {
+			t.Errorf("expected segment to be removed")
+		}
+	})
+
+	t.Run("cuts left side of straddling segment", func(t *testing.T) {
+		segment := Segment{LeftX: 4.0, LeftZ: -10.0, RightX: 4.0, RightZ: 30.0, Length: 40.0, OffsetU: 5.0}
+		if !line.Clip(&segment) {
+			t.Fatalf("expected segment to remain")
+		}
+		assertFloat(t, "left X", segment.LeftX, 4.0)
+		assertFloat(t, "left Z", segment.LeftZ, 0.0)
+		assertFloat(t, "right X", segment.RightX, 4.0)
+		assertFloat(t, "right Z", segment.RightZ, 30.0)
+		assertFloat(t, "length", segment.Length, 30.0)
+		assertFloat(t, "U offset", segment.OffsetU, 15.0)
+	})
+
+	t.Run("cuts right side of straddling segment", func(t *testing.T) {
+		segment := Segment{LeftX: 4.0, LeftZ: 30.0, RightX: 4.0, RightZ: -10.0, Length: 40.0, OffsetU: 5.0}
+		if !line.Clip(&segment) {
+			t.Fatalf("expected segment to remain")
+		}
+		assertFloat(t, "left X", segment.LeftX, 4.0)
+		assertFloat(t, "left Z", segment.LeftZ, 30.0)
+		assertFloat(t, "right X", segment.RightX, 4.0)
+		assertFloat(t, "right Z", segment.RightZ, 0.0)
+		assertFloat(t, "length", segment.Length, 30.0)
+		assertFloat(t, "U offset", segment.OffsetU, 5.0)
+	})
+}
+
+func TestClipToFace(t *testing.T) {
+	renderer := NewRenderer(graphics.NewImagePlotter(8, 6))
+	renderer.Clear()
+	// Column 3 has been drawn already and column 4 partially.
+	renderer.topClipScreenY[3] = 5
+	renderer.bottomClipScreenY[3] = 4
+	renderer.topClipScreenY[4] = 2
+
+	face := faceSurface{
+		LeftScreenX:   2,
+		RightScreenX:  5,
+		TopScreenY:    fixpoint.FromInt(1),
+		BottomScreenY: fixpoint.FromInt(4),
+	}
+	if !renderer.clipToFace(face) {
+		t.Fatalf("expected face to be open")
+	}
+	assertClip(t, renderer,
+		[]int{0, 0, 1, 5, 2, 1, 0, 0},
+		[]int{-1, -1, 4, 4, 4, 4, -1, -1},
+	)
+	if renderer.openClipCount != 3 {
+		t.Errorf("expected 3 open columns, got %d", renderer.openClipCount)
+	}
+
+	renderer.Clear()
+	for x := 2; x <= 5; x++ {
+		renderer.bottomClipScreenY[x] = 0
+	}
+	if renderer.clipToFace(face) {
+		t.Errorf("expected face to be closed")
+	}
+}
+
+func TestRenderMirror(t *testing.T) {
+	camera := NewCamera()
+	camera.SetPosition(5.0, 0.0, 10.0)
+	line := newMirrorLine(Segment{
+		LeftX:  0.0,
+		LeftZ:  0.0,
+		RightX: 10.0,
+		RightZ: 0.0,
+		Length: 10.0,
+	}, camera)
+	face := faceSurface{
+		LeftScreenX:   2,
+		RightScreenX:  5,
+		TopScreenY:    fixpoint.FromInt(1),
+		BottomScreenY: fixpoint.FromInt(4),
+	}
+
+	t.Run("falls back without view renderer", func(t *testing.T) {
+		renderer := NewRenderer(graphics.NewImagePlotter(8, 6))
+		renderer.Clear()
+		if renderer.renderMirror(camera, line, face) {
+			t.Errorf("expected reflection not to be rendered")
+		}
+	})
+
+	t.Run("restores clip state", func(t *testing.T) {
+		renderer := NewRenderer(graphics.NewImagePlotter(8, 6))
+		renderer.Clear()
+		renderer.topClipScreenY[4] = 2
+		renderer.openClipCount = 7
+		renderer.snapshotClip(0, 3)
+		renderer.occluders = append(renderer.occluders, occluder{})
+		renderer.translucentSegments = append(renderer.translucentSegments, translucentSegment{})
+
+		expectedTop := append([]int(nil), renderer.topClipScreenY...)
+		expectedBottom := append([]int(nil), renderer.bottomClipScreenY...)
+		expectedView := renderer.view
+		snapshotCount := len(renderer.snapshotTopClipScreenY)
+
+		var reflection *Camera
+		renderer.SetViewRenderer(func(viewCamera *Camera) {
+			reflection = viewCamera
+			if renderer.view.depth != 1 {
+				t.Errorf("expected view depth 1, got %d", renderer.view.depth)
+			}
+			if renderer.view.occluderStart != 1 || renderer.view.translucentStart != 1 {
+				t.Errorf("expected view to start after the existing occluders and translucent segments")
+			}
+			assertClip(t, renderer,
+				[]int{0, 0, 1, 1, 2, 1, 0, 0},
+				[]int{-1, -1, 4, 4, 4, 4, -1, -1},
+			)
+
+			// Draw everything that the reflection shows.
+			for x := 0; x < renderer.width; x++ {
+				renderer.topClipScreenY[x] = 0
+				renderer.bottomClipScreenY[x] = -1
+			}
+			renderer.openClipCount = 0
+			renderer.snapshotClip(0, renderer.width-1)
+			renderer.occluders = append(renderer.occluders, occluder{})
+			renderer.translucentSegments = append(renderer.translucentSegments, translucentSegment{})
+		})
+
+		if !renderer.renderMirror(camera, line, face) {
+			t.Fatalf("expected reflection to be rendered")
+		}
+		if reflection == nil {
+			t.Fatalf("expected view renderer to be called")
+		}
+		if !reflection.Mirrored() {
+			t.Errorf("expected reflected camera to be mirrored")
+		}
+		assertClip(t, renderer, expectedTop, expectedBottom)
+		if renderer.openClipCount != 7 {
+			t.Errorf("expected 7 open columns, got %d", renderer.openClipCount)
+		}
+		if count := len(renderer.snapshotTopClipScreenY); count != snapshotCount {
+			t.Errorf("expected %d snapshot top clips, got %d", snapshotCount, count)
+		}
+		if count := len(renderer.snapshotBottomClipScreenY); count != snapshotCount {
+			t.Errorf("expected %d snapshot bottom clips, got %d", snapshotCount, count)
+		}
+		if count := len(renderer.occluders); count != 1 {
+			t.Errorf("expected 1 occluder, got %d", count)
+		}
+		if count := len(renderer.translucentSegments); count != 1 {
+			t.Errorf("expected 1 translucent segment, got %d", count)
+		}
+		if renderer.view != expectedView {
+			t.Errorf("expected view %+v, got %+v", expectedView, renderer.view)
+		}
+	})
+
+	t.Run("limits nested reflections", func(t *testing.T) {
+		renderer := NewRenderer(graphics.NewImagePlotter(8, 6))
+		renderer.Clear()
+
+		rendered := make(map[int]bool)
+		renderer.SetViewRenderer(func(viewCamera *Camera) {
+			// Two facing mirrors would reflect each other indefinitely.
+			depth := renderer.view.depth
+			rendered[depth] = renderer.renderMirror(viewCamera, line, face)
+		})
+		if !renderer.renderMirror(camera, line, face) {
+			t.Fatalf("expected reflection to be rendered")
+		}
+		if len(rendered) != maxMirrorDepth {
+			t.Fatalf("expected %d nested views, got %d", maxMirrorDepth, len(rendered))
+		}
+		for depth := 1; depth <= maxMirrorDepth; depth++ {
+			if expected := depth < maxMirrorDepth; rendered[depth] != expected {
+				t.Errorf("expected reflection in view at depth %d to be rendered: %t, got %t", depth, expected, rendered[depth])
+			}
+		}
+		if renderer.view.depth != 0 {
+			t.Errorf("expected view depth 0, got %d", renderer.view.depth)
+		}
+	})
+}
+
+func assertClip(t *testing.T, renderer *Renderer, top, bottom []int) {
+	t.Helper()
+	for x := range top {
+		if renderer.topClipScreenY[x] != top[x] || renderer.bottomClipScreenY[x] != bottom[x] {
+			t.Errorf("expected clip [%d, %d] at column %d, got [%d, %d]", top[x], bottom[x], x, renderer.topClipScreenY[x], renderer.bottomClipScreenY[x])
+		}
+	}
+}
+
+func assertFloat(t *testing.T, name string, actual, expected float32) {
+	t.Helper()
+	if math.Abs(float64(actual-expected)) > 0.001 {
+		t.Errorf("expected %s %f, got %f", name, expected, actual)
+	}
+}
@@ -0,0 +1,86 @@
+package scene
+
+// clipSnapshot references a copy of the clip state of a range of screen
+// columns, as it was at a certain point while the frame was being drawn.
+type clipSnapshot struct {
+	LeftScreenX int
+	offset      int
+}
+
+// snapshotClip stores a copy of the clip state for the screen columns
+// between leftScreenX and rightScreenX (inclusive). The storage is reused
+// between frames, so no allocations occur once it has grown sufficiently.
+func (r *Renderer) snapshotClip(leftScreenX, rightScreenX int) clipSnapshot {
+	snapshot := clipSnapshot{
+		LeftScreenX: leftScreenX,
+		offset:      len(r.snapshotTopClipScreenY),
+	}
+	r.snapshotTopClipScreenY = append(r.snapshotTopClipScreenY, r.topClipScreenY[leftScreenX:rightScreenX+1]...)
+	r.snapshotBottomClipScreenY = append(r.snapshotBottomClipScreenY, r.bottomClipScreenY[leftScreenX:rightScreenX+1]...)
+	return snapshot
+}
+
+// snapshotTopClipScreenYAt returns the top clip of the specified screen
+// column, as it was when the snapshot was taken.
+func (r *Renderer) snapshotTopClipScreenYAt(s clipSnapshot, screenX int) int {
+	return r.snapshotTopClipScreenY[s.offset+screenX-s.LeftScreenX]
+}
+
+// snapshotBottomClipScreenYAt returns the bottom clip of the specified
+// screen column, as it was when the snapshot was taken.
+func (r *Renderer) snapshotBottomClipScreenYAt(s clipSnapshot, screenX int) int {
+	return r.snapshotBottomClipScreenY[s.offset+screenX-s.LeftScreenX]
+}
+
+// occluder records the screen area that has been drawn by a segment.
+// Since segments are drawn front to back, the clip state right after a
+// segment has been drawn describes all pixels that are at least as close
+// to the camera as the segment. This allows sprites and other translucent
+// surfaces to be clipped against nearer geometry at a later point.
+type occluder struct {
+	LeftScreenX  int
+	RightScreenX int
+
+	EQBottom      float32
+	EQBottomDelta float32
+	EQCross       float32
+
+	clip clipSnapshot
+}
+
+// ViewZ returns the distance from the camera to the segment of the occluder
+// at the specified screen column.
+func (o occluder) ViewZ(near float32, screenX int) float32 {
+	eqBottom := o.EQBottom + o.EQBottomDelta*float32(screenX-o.LeftScreenX)
+	return near * o.EQCross / eqBottom
+}
+
+// recordOccluder stores the occluder along with a snapshot of the clip
+// state for its screen columns.
+func (r *Renderer) recordOccluder(o occluder) {
+	o.clip = r.snapshotClip(o.LeftScreenX, o.RightScreenX)
+	r.occluders = append(r.occluders, o)
+}
+
+// clipBehindOccluders initializes the sprite clip arrays for the columns
+// between leftScreenX and rightScreenX (inclusive), such that everything
+// that was drawn closer to the camera than viewZ is excluded. Only the
+// occluders of the current view are considered and the clip arrays start
+// from the window of the view, which is the whole screen, unless a
+// reflection is being rendered.
+func (r *Renderer) clipBehindOccluders(leftScreenX, rightScreenX int, viewZ float32) {
+	for x := leftScreenX; x <= rightScreenX; x++ {
+		r.spriteTopClipScreenY[x] = r.snapshotTopClipScreenYAt(r.view.window, x)
+		r.spriteBottomClipScreenY[x] = r.snapshotBottomClipScreenYAt(r.view.window, x)
+	}
+	for _, o := range r.occluders[r.view.occluderStart:] {
+		fromScreenX := maxInt(o.LeftScreenX, leftScreenX)
+		toScreenX := minInt(o.RightScreenX, rightScreenX)
+		for x := fromScreenX; x <= toScreenX; x++ {
+			if o.ViewZ(float32(r.near), x) < viewZ {
+				r.spriteTopClipScreenY[x] = r.snapshotTopClipScreenYAt(o.clip, x)
+				r.spriteBottomClipScreenY[x] = r.snapshotBottomClipScreenYAt(o.clip, x)
+			}
+		}
+	}
+}
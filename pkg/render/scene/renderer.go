@@ -1,40 +1,30 @@
 package scene
 
 import (
-	"github.com/mokiat/softgfx/cmd/softgfx-wasm/internal/fixpoint"
-	"github.com/mokiat/softgfx/cmd/softgfx-wasm/internal/graphics"
-)
-
-const shadingFactor float32 = 0.2
+	"math"
 
-func NewRenderer(plotter *graphics.Plotter) *Renderer {
-	halfWidth := plotter.Width() / 2
-	halfHeight := plotter.Height() / 2
-
-	return &Renderer{
-		plotter: plotter,
+	"github.com/mokiat/softgfx/pkg/render/fixpoint"
+	"github.com/mokiat/softgfx/pkg/render/graphics"
+)
 
-		width:  plotter.Width(),
-		height: plotter.Height(),
-		near:   halfHeight,
-		minX:   -halfWidth,
-		maxX:   halfWidth - 1,
-		minY:   -halfHeight,
-		maxY:   halfHeight - 1,
+const defaultShadingFactor float32 = 0.2
 
-		fillLeftScreenX:   make([]int, plotter.Height()),
-		topClipScreenY:    make([]int, plotter.Width()),
-		bottomClipScreenY: make([]int, plotter.Width()),
-		openClipCount:     0,
+func NewRenderer(plotter graphics.Plotter) *Renderer {
+	renderer := &Renderer{
+		plotter:       plotter,
+		shadingFactor: defaultShadingFactor,
 	}
+	renderer.fitPlotter()
+	return renderer
 }
 
 type Renderer struct {
-	plotter *graphics.Plotter
+	plotter graphics.Plotter
 
 	width  int
 	height int
-	near   int
+	fov    float32 // the horizontal field of view of the camera, in degrees, from which near was derived
+	near   int     // the distance from the camera to the projection plane, in pixels
 	minX   int
 	maxX   int
 	minY   int
@@ -44,14 +34,136 @@ type Renderer struct {
 	fillLeftScreenX   []int // specifies the pixel (inclusive) from which drawing rightward is allowed during floodfill
 	topClipScreenY    []int // specifies the pixel (inclusive) from which drawing downward is allowed
 	bottomClipScreenY []int // specifies the pixel (inclusive) from which drawing upward is allowed
+
+	snapshotTopClipScreenY    []int // snapshots of topClipScreenY for the columns of occluders and translucent segments
+	snapshotBottomClipScreenY []int // snapshots of bottomClipScreenY for the columns of occluders and translucent segments
+	occluders                 []occluder
+	translucentSegments       []translucentSegment
+	visibleSprites            []visibleSprite
+	spriteTopClipScreenY      []int
+	spriteBottomClipScreenY   []int
+
+	shadingFactor float32
+	shadingTable  *graphics.ShadingTable
+	dithering     bool
+
+	viewRenderer  ViewRenderer
+	view          view
+	mirrorCameras [maxMirrorDepth]Camera
+}
+
+// updateProjection recalculates the distance to the projection plane, so
+// that the width of the drawing target spans the horizontal field of view
+// of the camera. Pixels are square, which keeps the projection correct
+// for any aspect ratio. Nothing is recalculated if the field of view has
+// not changed since the last call.
+func (r *Renderer) updateProjection(camera *Camera) {
+	if camera.fov == r.fov {
+		return
+	}
+	r.fov = camera.fov
+	r.near = int(math.Round(float64(r.width/2) / float64(camera.fovTan)))
+}
+
+// SetShadingFactor configures how quickly surfaces fade to black
+// as their distance from the camera increases.
+func (r *Renderer) SetShadingFactor(factor float32) {
+	r.shadingFactor = factor
+}
+
+// shadeAmount returns the amount by which a surface with the specified
+// light level is shaded when it is at the specified distance from the
+// camera. The light level ranges from 0.0 (black) to 1.0 (fully lit) and
+// scales the brightness that remains after distance shading. When
+// dithering, the fraction of the way to the next shade amount is returned
+// as well, so that the plotter can dither between the two. Otherwise, the
+// fraction is zero.
+func (r *Renderer) shadeAmount(viewZ, light float32) (int, int) {
+	if !r.dithering {
+		distanceShadeAmount := clampInt(int(r.shadingFactor*viewZ), 0, 255)
+		if light >= 1.0 {
+			return distanceShadeAmount, 0
+		}
+		return clampInt(255-int(light*float32(255-distanceShadeAmount)), 0, 255), 0
+	}
+	shade := clampFloat(r.shadingFactor*viewZ, 0.0, 255.0)
+	if light < 1.0 {
+		shade = 255.0 - light*(255.0-shade)
+	}
+	fineShade := clampInt(int(shade*graphics.ShadeFractions), 0, 255*graphics.ShadeFractions)
+	if fineShade == 255*graphics.ShadeFractions {
+		return 255, 0
+	}
+	return fineShade / graphics.ShadeFractions, fineShade % graphics.ShadeFractions
+}
+
+// SetShadingTable configures the shading table that is used for all
+// surfaces that do not specify their own. If it is nil, the default
+// shading table of the plotter is used.
+func (r *Renderer) SetShadingTable(table *graphics.ShadingTable) {
+	r.shadingTable = table
+}
+
+// surfaceShadingTable returns the shading table of a surface, falling
+// back to the shading table of the renderer.
+func (r *Renderer) surfaceShadingTable(table *graphics.ShadingTable) *graphics.ShadingTable {
+	if table == nil {
+		return r.shadingTable
+	}
+	return table
+}
+
+// SetDithering configures whether shading is dithered between adjacent
+// shade levels, which hides the bands that form on surfaces that span
+// a large range of distances, such as floors.
+func (r *Renderer) SetDithering(dithering bool) {
+	r.dithering = dithering
+}
+
+// fitPlotter updates the screen bounds and resizes the clip arrays to
+// match the size of the plotter, if it has changed.
+func (r *Renderer) fitPlotter() {
+	width := r.plotter.Width()
+	height := r.plotter.Height()
+	if (width == r.width) && (height == r.height) {
+		return
+	}
+
+	halfWidth := width / 2
+	halfHeight := height / 2
+	r.width = width
+	r.height = height
+	r.minX = -halfWidth
+	r.maxX = halfWidth - 1
+	r.minY = -halfHeight
+	r.maxY = halfHeight - 1
+
+	r.fillLeftScreenX = make([]int, height)
+	r.topClipScreenY = make([]int, width)
+	r.bottomClipScreenY = make([]int, width)
+	r.spriteTopClipScreenY = make([]int, width)
+	r.spriteBottomClipScreenY = make([]int, width)
+
+	// the projection depends on the width as well
+	r.fov = 0.0
 }
 
+// Clear prepares the renderer for a new frame. Since the size of the
+// plotter may change between frames, it is checked here as well.
 func (r *Renderer) Clear() {
+	r.fitPlotter()
 	for x := 0; x < r.width; x++ {
 		r.topClipScreenY[x] = 0
 		r.bottomClipScreenY[x] = r.height - 1
 	}
 	r.openClipCount = r.width
+	r.snapshotTopClipScreenY = r.snapshotTopClipScreenY[:0]
+	r.snapshotBottomClipScreenY = r.snapshotBottomClipScreenY[:0]
+	r.occluders = r.occluders[:0]
+	r.translucentSegments = r.translucentSegments[:0]
+	r.view = view{
+		window: r.snapshotClip(0, r.width-1),
+	}
 }
 
 func (r *Renderer) Saturated() bool {
@@ -59,19 +171,94 @@ func (r *Renderer) Saturated() bool {
 }
 
 func (r *Renderer) RenderSegment(segment Segment, camera *Camera) {
+	if !r.clipToMirror(&segment) {
+		return
+	}
+	var mirror mirrorLine
+	if segment.FaceMirror {
+		// The line is needed in world space, which the projection changes.
+		mirror = newMirrorLine(segment, camera)
+	}
+
+	face, ok := r.projectSegment(&segment, camera)
+	if !ok {
+		return
+	}
+
+	if segment.HasCeiling() {
+		r.renderCeiling(camera, ceilingSurface{
+			LeftScreenX:        face.LeftScreenX,
+			RightScreenX:       face.RightScreenX,
+			BottomScreenY:      face.TopScreenY,
+			BottomScreenYDelta: face.TopScreenYDelta,
+			ViewY:              segment.Top,
+			Texture:            segment.CeilingTexture,
+			Light:              segment.CeilingLight,
+			ShadingTable:       segment.CeilingShadingTable,
+			Mapping:            segment.CeilingMapping,
+			Sky:                segment.CeilingSky,
+		})
+	}
+
+	if segment.HasFloor() {
+		r.renderFloor(camera, floorSurface{
+			LeftScreenX:     face.LeftScreenX,
+			RightScreenX:    face.RightScreenX,
+			TopScreenY:      face.BottomScreenY,
+			TopScreenYDelta: face.BottomScreenYDelta,
+			ViewY:           segment.Bottom,
+			Texture:         segment.FloorTexture,
+			Light:           segment.FloorLight,
+			ShadingTable:    segment.FloorShadingTable,
+			Mapping:         segment.FloorMapping,
+			Sky:             segment.FloorSky,
+		})
+	}
+
+	if segment.HasFace() {
+		face.Texture = segment.FaceTexture
+		face.Light = segment.FaceLight
+		face.ShadingTable = segment.FaceShadingTable
+		face.Mapping = segment.FaceMapping
+		face.OffsetU = segment.OffsetU
+		face.AffectsTopClip = segment.HasCeiling()
+		face.AffectsBottomClip = segment.HasFloor()
+		face.Mirror = segment.FaceMirror && r.renderMirror(camera, mirror, face)
+		r.renderFace(camera, face)
+	}
+
+	r.recordOccluder(occluder{
+		LeftScreenX:   face.LeftScreenX,
+		RightScreenX:  face.RightScreenX,
+		EQBottom:      face.EQBottom,
+		EQBottomDelta: face.EQBottomDelta,
+		EQCross:       face.EQCross,
+	})
+}
+
+// projectSegment transforms the segment from world space to view space
+// and projects it onto the screen. The returned face surface has all of
+// its screen space properties set. The second return value is false if
+// the segment is not visible.
+func (r *Renderer) projectSegment(segment *Segment, camera *Camera) (faceSurface, bool) {
+	r.updateProjection(camera)
+
 	// Transform from world space to view space
 	segment.Translate(-camera.x, -camera.y, -camera.z)
 	segment.Rotate(camera.angleCos, -camera.angleSin)
+	if camera.mirrored {
+		segment.flipView()
+	}
 
 	if (segment.LeftZ <= 0) && (segment.RightZ <= 0) {
 		// Segment is behind camera. Don't render.
-		return
+		return faceSurface{}, false
 	}
 
 	eqCross := segment.LeftX*segment.RightZ - segment.RightX*segment.LeftZ
 	if eqCross >= 0 {
 		// We are seeing the back of the segment. Don't render
-		return
+		return faceSurface{}, false
 	}
 
 	// Project left edge to camera
@@ -102,7 +289,7 @@ func (r *Renderer) RenderSegment(segment Segment, camera *Camera) {
 
 	if (leftProjX > r.maxX) || (rightProjX < r.minX) {
 		// Segment is projected outside camera bounds. Don't render.
-		return
+		return faceSurface{}, false
 	}
 
 	// These are dynamic helper terms that are used in many equations below
@@ -118,46 +305,19 @@ func (r *Renderer) RenderSegment(segment Segment, camera *Camera) {
 	topProjYDelta := fixpoint.FromFloat32(segment.Top * (dz / eqCross))
 	bottomProjYDelta := fixpoint.FromFloat32(segment.Bottom * (dz / eqCross))
 
-	if segment.HasCeiling() {
-		r.renderCeiling(camera, ceilingSurface{
-			LeftScreenX:        leftProjX - r.minX,
-			RightScreenX:       rightProjX - r.minX,
-			BottomScreenY:      topProjY - fixpoint.FromInt(r.minY),
-			BottomScreenYDelta: topProjYDelta,
-			ViewY:              segment.Top,
-			Texture:            segment.CeilingTexture,
-		})
-	}
-
-	if segment.HasFloor() {
-		r.renderFloor(camera, floorSurface{
-			LeftScreenX:     leftProjX - r.minX,
-			RightScreenX:    rightProjX - r.minX,
-			TopScreenY:      bottomProjY - fixpoint.FromInt(r.minY),
-			TopScreenYDelta: bottomProjYDelta,
-			ViewY:           segment.Bottom,
-			Texture:         segment.FloorTexture,
-		})
-	}
-
-	if segment.HasFace() {
-		r.renderFace(camera, faceSurface{
-			LeftScreenX:        leftProjX - r.minX,
-			RightScreenX:       rightProjX - r.minX,
-			TopScreenY:         topProjY - fixpoint.FromInt(r.minY),
-			TopScreenYDelta:    topProjYDelta,
-			BottomScreenY:      bottomProjY - fixpoint.FromInt(r.minY),
-			BottomScreenYDelta: bottomProjYDelta,
-			EQTop:              eqTop,
-			EQTopDelta:         eqTopDelta,
-			EQBottom:           eqBottom,
-			EQBottomDelta:      eqBottomDelta,
-			EQCross:            eqCross,
-			Texture:            segment.FaceTexture,
-			AffectsTopClip:     segment.HasCeiling(),
-			AffectsBottomClip:  segment.HasFloor(),
-		})
-	}
+	return faceSurface{
+		LeftScreenX:        leftProjX - r.minX,
+		RightScreenX:       rightProjX - r.minX,
+		TopScreenY:         topProjY - fixpoint.FromInt(r.minY),
+		TopScreenYDelta:    topProjYDelta,
+		BottomScreenY:      bottomProjY - fixpoint.FromInt(r.minY),
+		BottomScreenYDelta: bottomProjYDelta,
+		EQTop:              eqTop,
+		EQTopDelta:         eqTopDelta,
+		EQBottom:           eqBottom,
+		EQBottomDelta:      eqBottomDelta,
+		EQCross:            eqCross,
+	}, true
 }
 
 type faceSurface struct {
@@ -175,9 +335,31 @@ type faceSurface struct {
 	EQBottomDelta float32
 	EQCross       float32
 	Texture       *graphics.Texture
+	Light         float32
+	ShadingTable  *graphics.ShadingTable
+	Mapping       TextureMapping
+	OffsetU       float32
 
 	AffectsTopClip    bool
 	AffectsBottomClip bool
+
+	// Mirror specifies that the reflection of the face has been drawn
+	// already, so only the clip arrays are updated.
+	Mirror bool
+}
+
+// TexelU returns the U texture coordinate of the specified distance along
+// the segment of the face. Faces with the default mapping truncate the
+// coordinate, so that the slightly negative distances that the
+// interpolation produces at the left edge show the first texel column.
+// Other mappings can produce negative coordinates across the whole face,
+// which are floored so that the texture repeats without a seam.
+func (f faceSurface) TexelU(distance float32) int {
+	u := f.Mapping.U(f.OffsetU + distance)
+	if f.Mapping == DefaultTextureMapping {
+		return int(u)
+	}
+	return floorInt(u)
 }
 
 func (r *Renderer) renderFace(camera *Camera, face faceSurface) {
@@ -203,17 +385,21 @@ func (r *Renderer) renderFace(camera *Camera, face faceSurface) {
 				currentBottomScreenY = r.bottomClipScreenY[x]
 			}
 
-			if currentTopScreenY <= currentBottomScreenY {
+			if (currentTopScreenY <= currentBottomScreenY) && !face.Mirror {
 				currentTopProjY := currentTopScreenY + r.minY
+				texShadeAmount, texShadeFraction := r.shadeAmount(float32(r.near)*eqCross/eqBottom, face.Light)
 				r.plotter.PlotVerticalStripe(graphics.VerticalStripe{
-					X:              x,
-					Top:            currentTopScreenY,
-					Bottom:         currentBottomScreenY,
-					TopU:           int(eqTop / eqBottom),
-					TopV:           fixpoint.FromFloat32((float32(currentTopProjY)-float32(r.near)*camera.skew)*(eqCross/eqBottom) + camera.y),
-					DeltaV:         fixpoint.FromFloat32(eqCross / eqBottom),
-					Texture:        face.Texture,
-					TexShadeAmount: clampInt(int(shadingFactor*float32(r.near)*eqCross/eqBottom), 0, 255),
+					X:                x,
+					Top:              currentTopScreenY,
+					Bottom:           currentBottomScreenY,
+					TopU:             face.TexelU(eqTop / eqBottom),
+					TopV:             fixpoint.FromFloat32(face.Mapping.V((float32(currentTopProjY)-float32(r.near)*camera.skew)*(eqCross/eqBottom) + camera.y)),
+					DeltaV:           fixpoint.FromFloat32(face.Mapping.ScaleV * eqCross / eqBottom),
+					Texture:          face.Texture,
+					TexShadeAmount:   texShadeAmount,
+					TexShadeFraction: texShadeFraction,
+					ShadingTable:     r.surfaceShadingTable(face.ShadingTable),
+					Dither:           r.dithering,
 				})
 			}
 
@@ -242,6 +428,10 @@ type ceilingSurface struct {
 	BottomScreenYDelta fixpoint.Value
 	ViewY              float32
 	Texture            *graphics.Texture
+	Light              float32
+	ShadingTable       *graphics.ShadingTable
+	Mapping            TextureMapping
+	Sky                bool
 }
 
 // renderCeiling renders a ceiling surface.
@@ -249,6 +439,11 @@ type ceilingSurface struct {
 // while trying to use as many horizontal lines as possible to maximize
 // reuse of math calculations.
 func (r *Renderer) renderCeiling(camera *Camera, ceiling ceilingSurface) {
+	if ceiling.Sky {
+		r.renderSkyCeiling(camera, ceiling)
+		return
+	}
+
 	bottomScreenY := ceiling.BottomScreenY
 	bottomScreenYDelta := ceiling.BottomScreenYDelta
 
@@ -289,6 +484,9 @@ func (r *Renderer) renderCeiling(camera *Camera, ceiling ceilingSurface) {
 						RightScreenX: x - 1,
 						ViewY:        ceiling.ViewY,
 						Texture:      ceiling.Texture,
+						Light:        ceiling.Light,
+						ShadingTable: ceiling.ShadingTable,
+						Mapping:      ceiling.Mapping,
 					})
 				}
 			}
@@ -322,6 +520,9 @@ func (r *Renderer) renderCeiling(camera *Camera, ceiling ceilingSurface) {
 						RightScreenX: x - 1,
 						ViewY:        ceiling.ViewY,
 						Texture:      ceiling.Texture,
+						Light:        ceiling.Light,
+						ShadingTable: ceiling.ShadingTable,
+						Mapping:      ceiling.Mapping,
 					})
 				}
 
@@ -338,6 +539,9 @@ func (r *Renderer) renderCeiling(camera *Camera, ceiling ceilingSurface) {
 						RightScreenX: x - 1,
 						ViewY:        ceiling.ViewY,
 						Texture:      ceiling.Texture,
+						Light:        ceiling.Light,
+						ShadingTable: ceiling.ShadingTable,
+						Mapping:      ceiling.Mapping,
 					})
 				}
 			}
@@ -366,6 +570,9 @@ func (r *Renderer) renderCeiling(camera *Camera, ceiling ceilingSurface) {
 				RightScreenX: ceiling.RightScreenX,
 				ViewY:        ceiling.ViewY,
 				Texture:      ceiling.Texture,
+				Light:        ceiling.Light,
+				ShadingTable: ceiling.ShadingTable,
+				Mapping:      ceiling.Mapping,
 			})
 		}
 	}
@@ -378,6 +585,10 @@ type floorSurface struct {
 	TopScreenYDelta fixpoint.Value
 	ViewY           float32
 	Texture         *graphics.Texture
+	Light           float32
+	ShadingTable    *graphics.ShadingTable
+	Mapping         TextureMapping
+	Sky             bool
 }
 
 // renderFloor renders a floor surface.
@@ -385,6 +596,11 @@ type floorSurface struct {
 // while trying to use as many horizontal lines as possible to maximize
 // reuse of math calculations.
 func (r *Renderer) renderFloor(camera *Camera, floor floorSurface) {
+	if floor.Sky {
+		r.renderSkyFloor(camera, floor)
+		return
+	}
+
 	topScreenY := floor.TopScreenY
 	topScreenYDelta := floor.TopScreenYDelta
 
@@ -425,6 +641,9 @@ func (r *Renderer) renderFloor(camera *Camera, floor floorSurface) {
 						RightScreenX: x - 1,
 						ViewY:        floor.ViewY,
 						Texture:      floor.Texture,
+						Light:        floor.Light,
+						ShadingTable: floor.ShadingTable,
+						Mapping:      floor.Mapping,
 					})
 				}
 			}
@@ -458,6 +677,9 @@ func (r *Renderer) renderFloor(camera *Camera, floor floorSurface) {
 						RightScreenX: x - 1,
 						ViewY:        floor.ViewY,
 						Texture:      floor.Texture,
+						Light:        floor.Light,
+						ShadingTable: floor.ShadingTable,
+						Mapping:      floor.Mapping,
 					})
 				}
 
@@ -474,6 +696,9 @@ func (r *Renderer) renderFloor(camera *Camera, floor floorSurface) {
 						RightScreenX: x - 1,
 						ViewY:        floor.ViewY,
 						Texture:      floor.Texture,
+						Light:        floor.Light,
+						ShadingTable: floor.ShadingTable,
+						Mapping:      floor.Mapping,
 					})
 				}
 			}
@@ -502,6 +727,9 @@ func (r *Renderer) renderFloor(camera *Camera, floor floorSurface) {
 				RightScreenX: floor.RightScreenX,
 				ViewY:        floor.ViewY,
 				Texture:      floor.Texture,
+				Light:        floor.Light,
+				ShadingTable: floor.ShadingTable,
+				Mapping:      floor.Mapping,
 			})
 		}
 	}
@@ -513,6 +741,9 @@ type surfaceStripe struct {
 	RightScreenX int
 	ViewY        float32
 	Texture      *graphics.Texture
+	Light        float32
+	ShadingTable *graphics.ShadingTable
+	Mapping      TextureMapping
 }
 
 // renderSurfaceStripe renders a horizontal line for a given surface (either floor or ceiling).
@@ -532,23 +763,28 @@ func (r *Renderer) renderSurfaceStripe(camera *Camera, stripe surfaceStripe) {
 	leftProjX := stripe.LeftScreenX + r.minX
 
 	ratio := stripe.ViewY / (float32(projY) - float32(r.near)*camera.skew)
+	viewXRatio := camera.viewSign() * ratio
 	surfaceViewZ := float32(r.near) * ratio
-	surfaceViewX := float32(leftProjX) * ratio
+	surfaceViewX := float32(leftProjX) * viewXRatio
 	surfaceWorldZ := surfaceViewX*camera.angleSin + surfaceViewZ*camera.angleCos + camera.z
 	surfaceWorldX := surfaceViewX*camera.angleCos - surfaceViewZ*camera.angleSin + camera.x
-	surfaceWorldZDelta := camera.angleSin * ratio
-	surfaceWorldXDelta := camera.angleCos * ratio
+	surfaceWorldZDelta := camera.angleSin * viewXRatio
+	surfaceWorldXDelta := camera.angleCos * viewXRatio
+	texShadeAmount, texShadeFraction := r.shadeAmount(surfaceViewZ, stripe.Light)
 
 	r.plotter.PlotHorizontalStripe(graphics.HorizontalStripe{
-		Y:              projY - r.minY,
-		Left:           stripe.LeftScreenX,
-		Right:          stripe.RightScreenX,
-		LeftU:          fixpoint.FromFloat32(surfaceWorldX),
-		LeftV:          fixpoint.FromFloat32(surfaceWorldZ),
-		DeltaU:         fixpoint.FromFloat32(surfaceWorldXDelta),
-		DeltaV:         fixpoint.FromFloat32(surfaceWorldZDelta),
-		Texture:        stripe.Texture,
-		TexShadeAmount: clampInt(int(shadingFactor*surfaceViewZ), 0, 255),
+		Y:                projY - r.minY,
+		Left:             stripe.LeftScreenX,
+		Right:            stripe.RightScreenX,
+		LeftU:            fixpoint.FromFloat32(stripe.Mapping.U(surfaceWorldX)),
+		LeftV:            fixpoint.FromFloat32(stripe.Mapping.V(surfaceWorldZ)),
+		DeltaU:           fixpoint.FromFloat32(stripe.Mapping.ScaleU * surfaceWorldXDelta),
+		DeltaV:           fixpoint.FromFloat32(stripe.Mapping.ScaleV * surfaceWorldZDelta),
+		Texture:          stripe.Texture,
+		TexShadeAmount:   texShadeAmount,
+		TexShadeFraction: texShadeFraction,
+		ShadingTable:     r.surfaceShadingTable(stripe.ShadingTable),
+		Dither:           r.dithering,
 	})
 }
 
@@ -561,3 +797,27 @@ func clampInt(value, min, max int) int {
 	}
 	return value
 }
+
+func clampFloat(value, min, max float32) float32 {
+	if value < min {
+		return min
+	}
+	if value > max {
+		return max
+	}
+	return value
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
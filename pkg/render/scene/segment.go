@@ -0,0 +1,93 @@
+package scene
+
+import "github.com/mokiat/softgfx/pkg/render/graphics"
+
+type Segment struct {
+	LeftX  float32
+	LeftZ  float32
+	RightX float32
+	RightZ float32
+	Length float32
+	Top    float32
+	Bottom float32
+
+	// OffsetU is added to the distance along the segment before the
+	// texture mapping of the face is applied.
+	OffsetU float32
+
+	CeilingTexture *graphics.Texture
+	FaceTexture    *graphics.Texture
+	FloorTexture   *graphics.Texture
+
+	// CeilingLight, FaceLight and FloorLight specify the light levels
+	// of the respective surfaces, ranging from 0.0 (black) to 1.0
+	// (fully lit).
+	CeilingLight float32
+	FaceLight    float32
+	FloorLight   float32
+
+	// CeilingShadingTable, FaceShadingTable and FloorShadingTable, if
+	// set, are used to shade the respective surfaces instead of the
+	// shading table of the Renderer.
+	CeilingShadingTable *graphics.ShadingTable
+	FaceShadingTable    *graphics.ShadingTable
+	FloorShadingTable   *graphics.ShadingTable
+
+	CeilingMapping TextureMapping
+	FaceMapping    TextureMapping
+	FloorMapping   TextureMapping
+
+	// CeilingSky and FloorSky specify that the respective surfaces show
+	// the sky, which is drawn as a panorama around the camera instead of
+	// as a flat surface. The texture mapping of such surfaces is ignored.
+	CeilingSky bool
+	FloorSky   bool
+
+	// FaceMirror specifies that the face reflects the scene, which is
+	// drawn through the ViewRenderer of the Renderer. The FaceTexture is
+	// still required, since it is shown where no reflection can be drawn.
+	FaceMirror bool
+}
+
+func (s Segment) HasCeiling() bool {
+	return s.CeilingTexture != nil
+}
+
+func (s Segment) HasFace() bool {
+	return s.FaceTexture != nil
+}
+
+func (s Segment) HasFloor() bool {
+	return s.FloorTexture != nil
+}
+
+func (s *Segment) Translate(x, y, z float32) {
+	s.LeftX += x
+	s.RightX += x
+	s.Top += y
+	s.Bottom += y
+	s.LeftZ += z
+	s.RightZ += z
+}
+
+func (s *Segment) Rotate(cos, sin float32) {
+	newX1 := s.LeftX*cos - s.LeftZ*sin
+	newZ1 := s.LeftX*sin + s.LeftZ*cos
+	s.LeftX = newX1
+	s.LeftZ = newZ1
+
+	newX2 := s.RightX*cos - s.RightZ*sin
+	newZ2 := s.RightX*sin + s.RightZ*cos
+	s.RightX = newX2
+	s.RightZ = newZ2
+}
+
+// flipView mirrors the segment in view space horizontally. The edges are
+// swapped, so that the segment keeps facing the same way, and the texture
+// mapping of the face is reversed, so that each point of the face keeps
+// its texel.
+func (s *Segment) flipView() {
+	s.LeftX, s.LeftZ, s.RightX, s.RightZ = -s.RightX, s.RightZ, -s.LeftX, s.LeftZ
+	s.OffsetU = -(s.OffsetU + s.Length)
+	s.FaceMapping.ScaleU = -s.FaceMapping.ScaleU
+}
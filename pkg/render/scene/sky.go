@@ -0,0 +1,92 @@
+package scene
+
+import (
+	"math"
+
+	"github.com/mokiat/softgfx/pkg/render/fixpoint"
+	"github.com/mokiat/softgfx/pkg/render/graphics"
+)
+
+// skyTurnTexels specifies how many texels of a sky texture span a full
+// turn of the camera. Narrower textures are repeated around the turn,
+// while wider ones are stretched to span it exactly once.
+const skyTurnTexels = 1024
+
+// renderSkyCeiling renders a ceiling surface that shows the sky.
+// Unlike regular ceilings, the sky is drawn in vertical stripes, since its
+// texture coordinates depend on the direction of each screen column and
+// not on the position of the camera.
+func (r *Renderer) renderSkyCeiling(camera *Camera, ceiling ceilingSurface) {
+	bottomScreenY := ceiling.BottomScreenY
+	for x := ceiling.LeftScreenX; x <= ceiling.RightScreenX; x++ {
+		currentTopScreenY := r.topClipScreenY[x]
+		currentBottomScreenY := bottomScreenY.Floor()
+		if currentBottomScreenY > r.bottomClipScreenY[x] {
+			currentBottomScreenY = r.bottomClipScreenY[x]
+		}
+		if currentTopScreenY <= currentBottomScreenY {
+			r.renderSkyStripe(camera, x, currentTopScreenY, currentBottomScreenY, ceiling.Texture, ceiling.Light, ceiling.ShadingTable)
+
+			r.topClipScreenY[x] = currentBottomScreenY + 1
+			if r.topClipScreenY[x] > r.bottomClipScreenY[x] {
+				r.openClipCount--
+			}
+		}
+		bottomScreenY += ceiling.BottomScreenYDelta
+	}
+}
+
+// renderSkyFloor renders a floor surface that shows the sky.
+// See renderSkyCeiling for more information.
+func (r *Renderer) renderSkyFloor(camera *Camera, floor floorSurface) {
+	topScreenY := floor.TopScreenY
+	for x := floor.LeftScreenX; x <= floor.RightScreenX; x++ {
+		currentTopScreenY := topScreenY.Floor()
+		if currentTopScreenY < r.topClipScreenY[x] {
+			currentTopScreenY = r.topClipScreenY[x]
+		}
+		currentBottomScreenY := r.bottomClipScreenY[x]
+		if currentTopScreenY <= currentBottomScreenY {
+			r.renderSkyStripe(camera, x, currentTopScreenY, currentBottomScreenY, floor.Texture, floor.Light, floor.ShadingTable)
+
+			r.bottomClipScreenY[x] = currentTopScreenY - 1
+			if r.topClipScreenY[x] > r.bottomClipScreenY[x] {
+				r.openClipCount--
+			}
+		}
+		topScreenY += floor.TopScreenYDelta
+	}
+}
+
+// renderSkyStripe renders a vertical line of the sky panorama.
+// The U coordinate is determined by the angle of the screen column
+// relative to the world and the V coordinate by the distance of the
+// screen row from the horizon, which is aligned with the bottom of the
+// texture. Both directions use the same number of texels per radian.
+func (r *Renderer) renderSkyStripe(camera *Camera, x, top, bottom int, texture *graphics.Texture, light float32, table *graphics.ShadingTable) {
+	turnTexels := float32(maxInt(skyTurnTexels, texture.Width))
+	texelsPerRadian := turnTexels / (2.0 * math.Pi)
+	texelsPerPixel := texelsPerRadian / float32(r.near)
+
+	projX := x + r.minX
+	cameraAngle := camera.angle * (math.Pi / 180.0)
+	columnAngle := cameraAngle - float32(math.Atan2(float64(camera.viewSign()*float32(projX)), float64(r.near)))
+
+	topProjY := top + r.minY
+	horizonProjY := float32(r.near) * camera.skew
+
+	texShadeAmount, texShadeFraction := r.shadeAmount(0.0, light)
+	r.plotter.PlotVerticalStripe(graphics.VerticalStripe{
+		X:                x,
+		Top:              top,
+		Bottom:           bottom,
+		TopU:             floorInt(-columnAngle * texelsPerRadian),
+		TopV:             fixpoint.FromFloat32(float32(texture.Height) + (float32(topProjY)-horizonProjY)*texelsPerPixel),
+		DeltaV:           fixpoint.FromFloat32(texelsPerPixel),
+		Texture:          texture,
+		TexShadeAmount:   texShadeAmount,
+		TexShadeFraction: texShadeFraction,
+		ShadingTable:     r.surfaceShadingTable(table),
+		Dither:           r.dithering,
+	})
+}
@@ -0,0 +1,127 @@
+package scene
+
+import (
+	"math"
+
+	"github.com/mokiat/softgfx/pkg/render/fixpoint"
+	"github.com/mokiat/softgfx/pkg/render/graphics"
+)
+
+// minSpriteViewZ is the closest distance from the camera at which
+// sprites are still drawn.
+const minSpriteViewZ float32 = 1.0
+
+// Sprite represents a textured rectangle that always faces the camera.
+// The X and Z coordinates specify the center of the sprite and the Y
+// coordinate specifies its bottom. The Light specifies the light level
+// of the sprite, ranging from 0.0 (black) to 1.0 (fully lit).
+type Sprite struct {
+	X       float32
+	Y       float32
+	Z       float32
+	Width   float32
+	Height  float32
+	Texture *graphics.Texture
+	Light   float32
+}
+
+type visibleSprite struct {
+	Sprite *Sprite
+	ViewX  float32
+	ViewY  float32
+	ViewZ  float32
+}
+
+type visibleSpritesByDepth []visibleSprite
+
+func (s visibleSpritesByDepth) Len() int           { return len(s) }
+func (s visibleSpritesByDepth) Less(i, j int) bool { return s[i].ViewZ > s[j].ViewZ }
+func (s visibleSpritesByDepth) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+
+// collectVisibleSprites transforms the specified sprites to view space
+// and keeps the ones that are in front of the camera.
+func (r *Renderer) collectVisibleSprites(sprites []Sprite, camera *Camera) {
+	r.visibleSprites = r.visibleSprites[:0]
+	for i := range sprites {
+		sprite := &sprites[i]
+		if sprite.Texture == nil {
+			continue
+		}
+		if (r.view.depth > 0) && (r.view.mirror.Distance(sprite.X, sprite.Z) <= 0.0) {
+			// Sprite is behind the mirror of a reflection. Don't render.
+			continue
+		}
+
+		// Transform from world space to view space
+		worldX := sprite.X - camera.x
+		worldZ := sprite.Z - camera.z
+		viewX := camera.viewSign() * (worldX*camera.angleCos + worldZ*camera.angleSin)
+		viewZ := -worldX*camera.angleSin + worldZ*camera.angleCos
+		if viewZ < minSpriteViewZ {
+			// Sprite is behind camera. Don't render.
+			continue
+		}
+
+		r.visibleSprites = append(r.visibleSprites, visibleSprite{
+			Sprite: sprite,
+			ViewX:  viewX,
+			ViewY:  sprite.Y - camera.y,
+			ViewZ:  viewZ,
+		})
+	}
+}
+
+func (r *Renderer) renderSprite(camera *Camera, sprite visibleSprite) {
+	texture := sprite.Sprite.Texture
+	scale := float32(r.near) / sprite.ViewZ
+
+	centerProjX := sprite.ViewX * scale
+	halfProjWidth := sprite.Sprite.Width * scale / 2.0
+	leftScreenX := centerProjX - halfProjWidth - float32(r.minX)
+	rightScreenX := centerProjX + halfProjWidth - float32(r.minX)
+
+	bottomProjY := sprite.ViewY*scale + camera.skew*float32(r.near)
+	topProjY := (sprite.ViewY-sprite.Sprite.Height)*scale + camera.skew*float32(r.near)
+	topScreenY := topProjY - float32(r.minY)
+	bottomScreenY := bottomProjY - float32(r.minY)
+
+	firstScreenX := maxInt(int(math.Ceil(float64(leftScreenX))), 0)
+	lastScreenX := minInt(int(math.Ceil(float64(rightScreenX)))-1, r.width-1)
+	firstScreenY := int(math.Ceil(float64(topScreenY)))
+	lastScreenY := int(math.Ceil(float64(bottomScreenY))) - 1
+	if (firstScreenX > lastScreenX) || (firstScreenY > lastScreenY) {
+		// Sprite is projected outside camera bounds. Don't render.
+		return
+	}
+
+	deltaU := float32(texture.Width) / (rightScreenX - leftScreenX)
+	deltaV := float32(texture.Height) / (bottomScreenY - topScreenY)
+	texShadeAmount, texShadeFraction := r.shadeAmount(sprite.ViewZ, sprite.Sprite.Light)
+
+	r.clipBehindOccluders(firstScreenX, lastScreenX, sprite.ViewZ)
+	for x := firstScreenX; x <= lastScreenX; x++ {
+		currentTopScreenY := maxInt(firstScreenY, r.spriteTopClipScreenY[x])
+		currentBottomScreenY := minInt(lastScreenY, r.spriteBottomClipScreenY[x])
+		if currentTopScreenY > currentBottomScreenY {
+			continue
+		}
+
+		u := clampInt(int((float32(x)-leftScreenX)*deltaU), 0, texture.Width-1)
+		if camera.mirrored {
+			u = texture.Width - 1 - u
+		}
+		r.plotter.PlotAlphaVerticalStripe(graphics.VerticalStripe{
+			X:                x,
+			Top:              currentTopScreenY,
+			Bottom:           currentBottomScreenY,
+			TopU:             u,
+			TopV:             fixpoint.FromFloat32((float32(currentTopScreenY) - topScreenY) * deltaV),
+			DeltaV:           fixpoint.FromFloat32(deltaV),
+			Texture:          texture,
+			TexShadeAmount:   texShadeAmount,
+			TexShadeFraction: texShadeFraction,
+			ShadingTable:     r.shadingTable,
+			Dither:           r.dithering,
+		})
+	}
+}
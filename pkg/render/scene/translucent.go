@@ -0,0 +1,106 @@
+package scene
+
+import (
+	"sort"
+
+	"github.com/mokiat/softgfx/pkg/render/fixpoint"
+	"github.com/mokiat/softgfx/pkg/render/graphics"
+)
+
+// translucentSegment is the face of a segment that has transparent or
+// translucent texels. Drawing of such faces is deferred until all opaque
+// geometry of the frame has been drawn.
+type translucentSegment struct {
+	Face  faceSurface
+	ViewZ float32
+	clip  clipSnapshot
+}
+
+// RenderTranslucentSegment queues the face of the specified segment to be
+// drawn by RenderTranslucent. Only the FaceTexture of the segment is used.
+// Unlike RenderSegment, it does not affect the clipping of geometry that
+// is rendered afterwards, since the geometry remains visible through
+// the transparent texels.
+func (r *Renderer) RenderTranslucentSegment(segment Segment, camera *Camera) {
+	if !segment.HasFace() || !r.clipToMirror(&segment) {
+		return
+	}
+	face, ok := r.projectSegment(&segment, camera)
+	if !ok {
+		return
+	}
+	face.Texture = segment.FaceTexture
+	face.Light = segment.FaceLight
+	face.ShadingTable = segment.FaceShadingTable
+	face.Mapping = segment.FaceMapping
+	face.OffsetU = segment.OffsetU
+
+	middleScreenX := (face.LeftScreenX + face.RightScreenX) / 2
+	eqBottom := face.EQBottom + face.EQBottomDelta*float32(middleScreenX-face.LeftScreenX)
+	r.translucentSegments = append(r.translucentSegments, translucentSegment{
+		Face:  face,
+		ViewZ: float32(r.near) * face.EQCross / eqBottom,
+		clip:  r.snapshotClip(face.LeftScreenX, face.RightScreenX),
+	})
+}
+
+// RenderTranslucent draws the queued translucent segments and the
+// specified sprites back to front. It should be called after all
+// segments of the frame have been rendered, since translucent
+// surfaces are clipped against the segments that are closer to
+// the camera. While a reflection is rendered, only the segments that
+// were queued for it are drawn.
+func (r *Renderer) RenderTranslucent(sprites []Sprite, camera *Camera) {
+	r.updateProjection(camera)
+	r.collectVisibleSprites(sprites, camera)
+	sort.Sort(visibleSpritesByDepth(r.visibleSprites))
+
+	// Segments are queued front to back, as that is the order in which
+	// the BSP tree is traversed, so they are drawn in reverse.
+	segmentIndex := len(r.translucentSegments) - 1
+	spriteIndex := 0
+	for (segmentIndex >= r.view.translucentStart) || (spriteIndex < len(r.visibleSprites)) {
+		if (spriteIndex < len(r.visibleSprites)) && ((segmentIndex < r.view.translucentStart) || (r.visibleSprites[spriteIndex].ViewZ > r.translucentSegments[segmentIndex].ViewZ)) {
+			r.renderSprite(camera, r.visibleSprites[spriteIndex])
+			spriteIndex++
+		} else {
+			r.renderTranslucentSegment(camera, r.translucentSegments[segmentIndex])
+			segmentIndex--
+		}
+	}
+}
+
+func (r *Renderer) renderTranslucentSegment(camera *Camera, segment translucentSegment) {
+	face := segment.Face
+	topScreenY := face.TopScreenY
+	bottomScreenY := face.BottomScreenY
+	eqTop := face.EQTop
+	eqBottom := face.EQBottom
+
+	for x := face.LeftScreenX; x <= face.RightScreenX; x++ {
+		currentTopScreenY := maxInt(topScreenY.Floor(), r.snapshotTopClipScreenYAt(segment.clip, x))
+		currentBottomScreenY := minInt(bottomScreenY.Floor(), r.snapshotBottomClipScreenYAt(segment.clip, x))
+		if currentTopScreenY <= currentBottomScreenY {
+			currentTopProjY := currentTopScreenY + r.minY
+			texShadeAmount, texShadeFraction := r.shadeAmount(float32(r.near)*face.EQCross/eqBottom, face.Light)
+			r.plotter.PlotAlphaVerticalStripe(graphics.VerticalStripe{
+				X:                x,
+				Top:              currentTopScreenY,
+				Bottom:           currentBottomScreenY,
+				TopU:             face.TexelU(eqTop / eqBottom),
+				TopV:             fixpoint.FromFloat32(face.Mapping.V((float32(currentTopProjY)-float32(r.near)*camera.skew)*(face.EQCross/eqBottom) + camera.y)),
+				DeltaV:           fixpoint.FromFloat32(face.Mapping.ScaleV * face.EQCross / eqBottom),
+				Texture:          face.Texture,
+				TexShadeAmount:   texShadeAmount,
+				TexShadeFraction: texShadeFraction,
+				ShadingTable:     r.surfaceShadingTable(face.ShadingTable),
+				Dither:           r.dithering,
+			})
+		}
+
+		topScreenY += face.TopScreenYDelta
+		bottomScreenY += face.BottomScreenYDelta
+		eqTop += face.EQTopDelta
+		eqBottom += face.EQBottomDelta
+	}
+}